@@ -3,48 +3,131 @@ package main
 import "fmt"
 import "log"
 import "bufio"
+import "flag"
 import "os"
 import "strconv"
 
 func main() {
+	solve := flag.Bool("solve", false, "find a minimal list of rotations producing -target zero counts")
+	repair := flag.Bool("repair", false, "edit the fewest rotations read from stdin so they produce -target zero counts")
+	spaceMax := flag.Int("size", 100, "number of positions on the dial")
+	start := flag.Int("start", 50, "starting dial position")
+	target := flag.Int("target", 0, "desired zero count for -solve and -repair")
+	maxStep := flag.Int("max-step", 0, "largest rotation amount the solver may emit (0 means size - 1)")
+	flag.Parse()
+
+	if *solve || *repair {
+		step := *maxStep
+		if step == 0 {
+			step = *spaceMax - 1
+		}
+		solver, err := NewSolver(*spaceMax, *start, step)
+		if err != nil {
+			log.Fatalf("Failure: %v", err)
+		}
+		var rotations []Rotation
+		if *solve {
+			rotations, err = solver.Solve(*target)
+		} else {
+			rotations, err = readRotations(bufio.NewScanner(os.Stdin))
+			if err != nil {
+				log.Fatalf("Failure: %v", err)
+			}
+			var edits int
+			rotations, edits, err = solver.Repair(rotations, *target)
+			if err == nil {
+				log.Printf("Edited lines: %v", edits)
+			}
+		}
+		if err != nil {
+			log.Fatalf("Failure: %v", err)
+		}
+		for _, r := range rotations {
+			fmt.Println(r)
+		}
+		return
+	}
+
 	fmt.Println("Hello, world")
 	scanner := bufio.NewScanner(os.Stdin)
-	spaceMax := 100
-	current := 50
+	current := *start
 	count := 0
 	for scanner.Scan() {
-		input := scanner.Text()
-		direction := input[0]
-		numStr := input[1:]
-		num, err := strconv.Atoi(numStr)
+		r, err := parseRotation(scanner.Text())
 		if err != nil {
-			log.Fatal("Atoi failed")
+			log.Fatalf("Failure: %v", err)
 		}
+		zeros := 0
+		current, zeros = rotate(current, *spaceMax, r.direction, r.amount)
+		count += zeros
+	}
+	fmt.Printf("Total count: %v", count)
+}
 
-		// handle full rotations
-		fullRotations := num / spaceMax
-		count += fullRotations
-		num = num % spaceMax
-		if num == 0 {
-			continue
-		}
+// rotate turns the dial from current by num positions in direction ('L' or
+// 'R') and returns the new position along with how many times the dial
+// pointed at zero along the way.
+func rotate(current, spaceMax int, direction byte, num int) (int, int) {
+	count := 0
 
-		// handle sign
-		sign := 1
-		if direction == 'L' {
-			sign = -1
-		}
-		num = num * sign
-
-		prevCur := current
-		current = ((current + num) + spaceMax) % spaceMax
-		if sign == -1 && prevCur <= current && prevCur != 0 {
-			count++
-		} else if sign == 1 && prevCur >= current {
-			count++
-		} else if current == 0 {
-			count++
+	// handle full rotations
+	fullRotations := num / spaceMax
+	count += fullRotations
+	num = num % spaceMax
+	if num == 0 {
+		return current, count
+	}
+
+	// handle sign
+	sign := 1
+	if direction == 'L' {
+		sign = -1
+	}
+	num = num * sign
+
+	prevCur := current
+	current = ((current + num) + spaceMax) % spaceMax
+	if sign == -1 && prevCur <= current && prevCur != 0 {
+		count++
+	} else if sign == 1 && prevCur >= current {
+		count++
+	} else if current == 0 {
+		count++
+	}
+	return current, count
+}
+
+type Rotation struct {
+	direction byte
+	amount    int
+}
+
+func (r Rotation) String() string {
+	return string(r.direction) + strconv.Itoa(r.amount)
+}
+
+func parseRotation(input string) (Rotation, error) {
+	if len(input) < 2 || (input[0] != 'L' && input[0] != 'R') {
+		return Rotation{}, fmt.Errorf("Rotation must look like L<n> or R<n>. Got %q", input)
+	}
+	num, err := strconv.Atoi(input[1:])
+	if err != nil {
+		return Rotation{}, err
+	}
+	if num < 0 {
+		return Rotation{}, fmt.Errorf("Rotation amount must be >= 0. Got %v", num)
+	}
+	return Rotation{direction: input[0], amount: num}, nil
+}
+
+func readRotations(scanner *bufio.Scanner) ([]Rotation, error) {
+	rotations := make([]Rotation, 0)
+	for scanner.Scan() {
+		r, err := parseRotation(scanner.Text())
+		if err != nil {
+			return nil, err
 		}
+		rotations = append(rotations, r)
 	}
-	fmt.Printf("Total count: %v", count)
+	return rotations, nil
 }
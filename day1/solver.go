@@ -0,0 +1,220 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+/*
+The solver works backwards from a desired zero count. A state is the pair (dial position, zeros counted so far),
+and every rotation moves between states using the same arithmetic as rotate. Counts only ever go up, so any state
+whose count passes the target can be dropped, which keeps the state space at size * (target + 1).
+
+Solve is a BFS over that space, so the first time a state with count == target is reached we have a minimal list.
+Repair walks the given list line by line, keeping the cheapest (fewest edits) way to reach every state after each
+line. A line is either kept as is for free, or replaced by any rotation the solver is allowed to emit for one edit.
+Only the rotation chosen for every line and state is stored, since a rotation moves the dial by a fixed step and can
+be undone to find the state before it.
+*/
+
+type Solver struct {
+	spaceMax int
+	start    int
+	maxStep  int
+	// moves[pos] holds one rotation for every distinct (new position, zeros) outcome reachable from pos
+	moves [][]move
+}
+
+type move struct {
+	rotation Rotation
+	next     int
+	zeros    int
+}
+
+func NewSolver(spaceMax, start, maxStep int) (*Solver, error) {
+	if spaceMax < 2 {
+		return nil, fmt.Errorf("Dial size must be >= 2. Got %v", spaceMax)
+	}
+	if start < 0 || start >= spaceMax {
+		return nil, fmt.Errorf("Start position must be in [0, %v). Got %v", spaceMax, start)
+	}
+	if maxStep < 1 {
+		return nil, fmt.Errorf("Max step must be >= 1. Got %v", maxStep)
+	}
+	s := &Solver{spaceMax: spaceMax, start: start, maxStep: maxStep, moves: make([][]move, spaceMax)}
+	for pos := 0; pos < spaceMax; pos++ {
+		seen := make(map[[2]int]bool)
+		for amount := 1; amount <= maxStep; amount++ {
+			for _, direction := range []byte{'R', 'L'} {
+				next, zeros := rotate(pos, spaceMax, direction, amount)
+				key := [2]int{next, zeros}
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				s.moves[pos] = append(s.moves[pos], move{rotation: Rotation{direction: direction, amount: amount}, next: next, zeros: zeros})
+			}
+		}
+	}
+	return s, nil
+}
+
+func (s *Solver) state(pos, count int) int {
+	return count*s.spaceMax + pos
+}
+
+// Solve returns a shortest list of rotations that, starting from the solver's
+// start position, points the dial at zero exactly target times.
+func (s *Solver) Solve(target int) ([]Rotation, error) {
+	if target < 0 {
+		return nil, fmt.Errorf("Target must be >= 0. Got %v", target)
+	}
+	numStates := s.spaceMax * (target + 1)
+	prev := make([]int, numStates)
+	via := make([]Rotation, numStates)
+	for i := range prev {
+		prev[i] = -1
+	}
+	first := s.state(s.start, 0)
+	prev[first] = first
+	queue := []int{first}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		pos, count := cur%s.spaceMax, cur/s.spaceMax
+		if count == target {
+			return s.path(prev, via, first, cur), nil
+		}
+		for _, m := range s.moves[pos] {
+			nextCount := count + m.zeros
+			if nextCount > target {
+				continue
+			}
+			next := s.state(m.next, nextCount)
+			if prev[next] != -1 {
+				continue
+			}
+			prev[next] = cur
+			via[next] = m.rotation
+			queue = append(queue, next)
+		}
+	}
+	return nil, errors.New("No list of rotations reaches the target")
+}
+
+func (s *Solver) path(prev []int, via []Rotation, first, last int) []Rotation {
+	rotations := make([]Rotation, 0)
+	for cur := last; cur != first; cur = prev[cur] {
+		rotations = append(rotations, via[cur])
+	}
+	for i, j := 0, len(rotations)-1; i < j; i, j = i+1, j-1 {
+		rotations[i], rotations[j] = rotations[j], rotations[i]
+	}
+	return rotations
+}
+
+// maxRepairCells bounds lines * states for Repair, which stores 2 bytes per
+// cell: 1 << 28 cells is 512 MiB.
+const maxRepairCells = 1 << 28
+
+// step returns the signed distance a rotation moves the dial, R positive.
+func (r Rotation) step() int {
+	if r.direction == 'L' {
+		return -r.amount
+	}
+	return r.amount
+}
+
+// undo returns the position and zero count before r, given those after it.
+func (s *Solver) undo(r Rotation, pos, count int) (int, int) {
+	before := ((pos-r.step())%s.spaceMax + s.spaceMax) % s.spaceMax
+	_, zeros := rotate(before, s.spaceMax, r.direction, r.amount)
+	return before, count - zeros
+}
+
+// Repair returns a copy of rotations with the fewest lines replaced so that
+// the dial points at zero exactly target times, along with the number of
+// replaced lines. It stores the rotation chosen for every line and state, so
+// lines * size * (target + 1) must stay within maxRepairCells. The full puzzle
+// input is well beyond that.
+func (s *Solver) Repair(rotations []Rotation, target int) ([]Rotation, int, error) {
+	if target < 0 {
+		return nil, 0, fmt.Errorf("Target must be >= 0. Got %v", target)
+	}
+	if s.maxStep > math.MaxInt16 {
+		return nil, 0, fmt.Errorf("Max step must be <= %v to repair. Got %v", math.MaxInt16, s.maxStep)
+	}
+	const unreachable = -1
+	// kept marks a line left as is, any other choice is the step of its replacement
+	const kept = 0
+	numStates := s.spaceMax * (target + 1)
+	numLines := len(rotations)
+	if cells := numLines * numStates; numLines > 0 && (cells/numLines != numStates || cells > maxRepairCells) {
+		return nil, 0, fmt.Errorf("Repairing %v lines to %v zeros on a dial of %v needs more than %v states. Use a shorter list or a lower target",
+			numLines, target, s.spaceMax, maxRepairCells)
+	}
+	edits := make([]int, numStates)
+	for i := range edits {
+		edits[i] = unreachable
+	}
+	edits[s.state(s.start, 0)] = 0
+	// choice[i][state] is how line i reached state
+	choice := make([][]int16, numLines)
+	for i, r := range rotations {
+		nextEdits := make([]int, numStates)
+		for j := range nextEdits {
+			nextEdits[j] = unreachable
+		}
+		choice[i] = make([]int16, numStates)
+		relax := func(to, cost int, c int16) {
+			if nextEdits[to] == unreachable || cost < nextEdits[to] {
+				nextEdits[to] = cost
+				choice[i][to] = c
+			}
+		}
+		for cur, e := range edits {
+			if e == unreachable {
+				continue
+			}
+			pos, count := cur%s.spaceMax, cur/s.spaceMax
+			next, zeros := rotate(pos, s.spaceMax, r.direction, r.amount)
+			if count+zeros <= target {
+				relax(s.state(next, count+zeros), e, kept)
+			}
+			for _, m := range s.moves[pos] {
+				if count+m.zeros <= target {
+					relax(s.state(m.next, count+m.zeros), e+1, int16(m.rotation.step()))
+				}
+			}
+		}
+		edits = nextEdits
+	}
+
+	best := unreachable
+	for pos := 0; pos < s.spaceMax; pos++ {
+		e := edits[s.state(pos, target)]
+		if e != unreachable && (best == unreachable || e < edits[best]) {
+			best = s.state(pos, target)
+		}
+	}
+	if best == unreachable {
+		return nil, 0, errors.New("No repair of the rotations reaches the target")
+	}
+
+	repaired := make([]Rotation, numLines)
+	pos, count := best%s.spaceMax, target
+	for i := numLines - 1; i >= 0; i-- {
+		c := int(choice[i][s.state(pos, count)])
+		switch {
+		case c == kept:
+			repaired[i] = rotations[i]
+		case c > 0:
+			repaired[i] = Rotation{direction: 'R', amount: c}
+		default:
+			repaired[i] = Rotation{direction: 'L', amount: -c}
+		}
+		pos, count = s.undo(repaired[i], pos, count)
+	}
+	return repaired, edits[best], nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// countZeros replays rotations from start and returns the zero count.
+func countZeros(spaceMax, start int, rotations []Rotation) int {
+	current, count := start, 0
+	for _, r := range rotations {
+		zeros := 0
+		current, zeros = rotate(current, spaceMax, r.direction, r.amount)
+		count += zeros
+	}
+	return count
+}
+
+func parseRotations(t *testing.T, lines []string) []Rotation {
+	t.Helper()
+	rotations := make([]Rotation, len(lines))
+	for i, line := range lines {
+		r, err := parseRotation(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rotations[i] = r
+	}
+	return rotations
+}
+
+func Test_Solve(t *testing.T) {
+	data := []struct {
+		name     string
+		spaceMax int
+		start    int
+		maxStep  int
+		target   int
+		expected int
+	}{
+		{"target_0", 100, 50, 99, 0, 0},
+		{"one_zero", 100, 50, 99, 1, 1},
+		// passing zero counts, so every rotation can cross it once
+		{"three_zeros", 100, 50, 99, 3, 3},
+		{"full_rotations", 100, 50, 250, 3, 1},
+		{"start_at_zero", 10, 0, 9, 1, 2},
+		{"single_steps", 10, 5, 1, 1, 5},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			solver, err := NewSolver(d.spaceMax, d.start, d.maxStep)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rotations, err := solver.Solve(d.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rotations) != d.expected {
+				t.Errorf("Expected %v, got %v (%v)", d.expected, len(rotations), rotations)
+			}
+			if count := countZeros(d.spaceMax, d.start, rotations); count != d.target {
+				t.Errorf("Expected %v, got %v", d.target, count)
+			}
+		})
+	}
+	solver, _ := NewSolver(100, 50, 99)
+	if _, err := solver.Solve(-1); err == nil {
+		t.Errorf("Expected an error for a negative target")
+	}
+}
+
+// bruteRepair tries every combination of kept and replaced lines and returns
+// the fewest edits reaching target, or -1.
+func bruteRepair(s *Solver, rotations []Rotation, target int) int {
+	best := -1
+	var walk func(i, pos, count, edits int)
+	walk = func(i, pos, count, edits int) {
+		if count > target || (best != -1 && edits >= best) {
+			return
+		}
+		if i == len(rotations) {
+			if count == target {
+				best = edits
+			}
+			return
+		}
+		next, zeros := rotate(pos, s.spaceMax, rotations[i].direction, rotations[i].amount)
+		walk(i+1, next, count+zeros, edits)
+		for _, m := range s.moves[pos] {
+			walk(i+1, m.next, count+m.zeros, edits+1)
+		}
+	}
+	walk(0, s.start, 0, 0)
+	return best
+}
+
+func Test_Repair(t *testing.T) {
+	example := []string{"L68", "L30", "R48", "L5", "R60", "L55", "L1", "L99", "R14", "L82"}
+	data := []struct {
+		name     string
+		spaceMax int
+		start    int
+		maxStep  int
+		lines    []string
+		target   int
+		expected int
+	}{
+		{"example_unchanged", 100, 50, 99, example, 6, 0},
+		{"example_target_0", 100, 50, 99, example, 0, 3},
+		{"example_one_more", 100, 50, 99, example, 7, 1},
+		// every rotation is under a full turn, so each line passes zero at most once
+		{"example_most_zeros", 100, 50, 99, example, 10, 3},
+		{"example_unreachable", 100, 50, 99, example, 11, -1},
+		{"small_dial_target_0", 10, 3, 9, []string{"R7", "L4", "R14"}, 0, 2},
+		{"small_dial_unchanged", 10, 3, 9, []string{"R7", "L4", "R14"}, 3, 0},
+		{"small_dial_target_4", 10, 3, 9, []string{"R7", "L4", "R14"}, 4, 1},
+		{"small_dial_unreachable", 10, 3, 9, []string{"R7", "L4", "R14"}, 5, -1},
+		{"no_lines_target_0", 10, 3, 9, []string{}, 0, 0},
+		{"unreachable_no_lines", 10, 3, 9, []string{}, 1, -1},
+		{"unreachable_too_few_lines", 100, 50, 99, []string{"R1", "R1"}, 3, -1},
+		{"multi_turn_lines_unchanged", 10, 3, 9, []string{"R27", "L45", "R9"}, 8, 0},
+		{"multi_turn_lines_target_3", 10, 3, 9, []string{"R27", "L45", "R9"}, 3, 2},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			solver, err := NewSolver(d.spaceMax, d.start, d.maxStep)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rotations := parseRotations(t, d.lines)
+			if len(rotations) <= 3 {
+				if brute := bruteRepair(solver, rotations, d.target); brute != d.expected {
+					t.Fatalf("Brute force: expected %v, got %v", d.expected, brute)
+				}
+			}
+			repaired, edits, err := solver.Repair(rotations, d.target)
+			if d.expected == -1 {
+				if err == nil {
+					t.Errorf("Expected an error, got %v", repaired)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if edits != d.expected {
+				t.Errorf("Expected %v, got %v", d.expected, edits)
+			}
+			changed := 0
+			for i := range rotations {
+				if repaired[i] != rotations[i] {
+					changed++
+				}
+			}
+			if changed > edits {
+				t.Errorf("Expected at most %v changed lines, got %v", edits, changed)
+			}
+			if count := countZeros(d.spaceMax, d.start, repaired); count != d.target {
+				t.Errorf("Expected %v, got %v", d.target, count)
+			}
+		})
+	}
+}
+
+func Test_Repair_limit(t *testing.T) {
+	solver, err := NewSolver(100, 50, 99)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotations := make([]Rotation, 4445)
+	for i := range rotations {
+		rotations[i] = Rotation{direction: 'R', amount: 1}
+	}
+	_, _, err = solver.Repair(rotations, 6700)
+	if err == nil {
+		t.Errorf("Expected an error for %v lines", len(rotations))
+	}
+	solver, err = NewSolver(10, 3, math.MaxInt16+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = solver.Repair(rotations[:1], 1)
+	if err == nil {
+		t.Errorf("Expected an error for max step %v", math.MaxInt16+1)
+	}
+}
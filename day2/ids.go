@@ -0,0 +1,107 @@
+package main
+
+import (
+	"iter"
+	"math/big"
+)
+
+// RepeatedID is an invalid ID made of Block repeated Repeats times. When an ID
+// repeats in several ways (111111), Block is the shortest one among the repeat
+// counts allowed: with repeatCountsPart1, 1111 is 11 repeated twice.
+type RepeatedID struct {
+	ID      *big.Int
+	Block   *big.Int
+	Repeats int
+}
+
+// repeatCountsPart1 allows only IDs made of a block repeated exactly twice.
+func repeatCountsPart1(m int) []int {
+	if m%2 != 0 {
+		return nil
+	}
+	return []int{2}
+}
+
+// repeatCountsPart2 allows IDs made of a block repeated any number of times.
+func repeatCountsPart2(m int) []int {
+	counts := make([]int, 0)
+	for k := 2; k <= m; k++ {
+		if m%k == 0 {
+			counts = append(counts, k)
+		}
+	}
+	return counts
+}
+
+type blockCursor struct {
+	repeats    int
+	multiplier *big.Int
+	block      *big.Int
+	last       *big.Int
+	value      *big.Int
+}
+
+/*
+repeatedIDs yields every ID in [lower, upper] whose length m allows one of repeatCounts(m), in ascending order.
+
+For a fixed length m and repeat count k, the matching IDs are block * M for consecutive blocks (see getBlockBounds),
+so each k is an ascending cursor that steps by M. The cursors of every k are merged, always yielding the smallest
+value. An ID reachable through several k is yielded once, and with the largest k, which is its shortest block.
+*/
+func repeatedIDs(lower, upper *big.Int, repeatCounts func(m int) []int) iter.Seq[RepeatedID] {
+	return func(yield func(RepeatedID) bool) {
+		if upper.Cmp(lower) < 0 {
+			return
+		}
+		one := big.NewInt(1)
+		for m := len(lower.String()); m <= len(upper.String()); m++ {
+			mLower := maxBig(lower, getLowerOfMNum(m))
+			mUpper := minBig(upper, getUpperOfMNum(m))
+			cursors := make([]*blockCursor, 0)
+			for _, k := range repeatCounts(m) {
+				lowest, highest, multiplier := getBlockBounds(mLower, mUpper, m/k, k, 10)
+				if highest.Cmp(lowest) < 0 {
+					continue
+				}
+				cursors = append(cursors, &blockCursor{
+					repeats:    k,
+					multiplier: multiplier,
+					block:      new(big.Int).Set(lowest),
+					last:       highest,
+					value:      new(big.Int).Mul(lowest, multiplier),
+				})
+			}
+			for len(cursors) > 0 {
+				var smallest *blockCursor
+				for _, c := range cursors {
+					cmp := 1
+					if smallest != nil {
+						cmp = smallest.value.Cmp(c.value)
+					}
+					if cmp > 0 || (cmp == 0 && c.repeats > smallest.repeats) {
+						smallest = c
+					}
+				}
+				id := RepeatedID{
+					ID:      new(big.Int).Set(smallest.value),
+					Block:   new(big.Int).Set(smallest.block),
+					Repeats: smallest.repeats,
+				}
+				if !yield(id) {
+					return
+				}
+				remaining := cursors[:0]
+				for _, c := range cursors {
+					if c.value.Cmp(id.ID) == 0 {
+						c.block.Add(c.block, one)
+						c.value.Add(c.value, c.multiplier)
+					}
+					if c.block.Cmp(c.last) <= 0 {
+						remaining = append(remaining, c)
+					}
+				}
+				cursors = remaining
+			}
+		}
+	}
+}
@@ -1,71 +1,121 @@
 package main
 
 import (
-	"bufio"
 	"errors"
+	"flag"
 	"fmt"
 	"log"
-	"os"
+	"math/big"
+	"runtime"
 	"strconv"
 	"strings"
 )
 
 func main() {
-	main_part2()
+	part := flag.Int("part", 0, "puzzle part to solve (1 or 2). Both by default")
+	list := flag.Bool("list", false, "print every invalid ID along with its block and repeat count")
+	patternName := flag.String("pattern", "", "count and sum IDs matching a pattern: exact, atleast, palindrome or multiset")
+	k := flag.Int("k", 2, "repeat count for the exact and atleast patterns")
+	digits := flag.String("digits", "", "digits for the multiset pattern")
+	base := flag.Int("base", 10, "base the ranges and patterns are written in (2 - 36)")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of ranges analyzed in parallel")
+	flag.Parse()
+	if *patternName != "" {
+		p, err := newPattern(*patternName, *k, *digits)
+		if err != nil {
+			log.Fatalf("Failure: %v", err)
+		}
+		main_pattern(p, *base, *workers)
+	} else if *list {
+		main_list(*part)
+	} else {
+		main_parts(*part, *workers)
+	}
 }
 
-func main_part2() {
-	scanner := bufio.NewScanner(os.Stdin)
-	var allRanges string
-	if scanner.Scan() {
-		allRanges = scanner.Text()
+func main_list(part int) {
+	repeatCounts := repeatCountsPart2
+	if part == 1 {
+		repeatCounts = repeatCountsPart1
 	}
-	ranges := strings.Split(allRanges, ",")
-	result := int64(0)
-	for i := range ranges {
-		r := ranges[i]
-		rSplit := strings.Split(r, "-")
-		rLower := rSplit[0]
-		rUpper := rSplit[1]
-		log.Printf("Range # %3v [%12v - %12v]", i, rLower, rUpper)
-		rangeResult, err := analyzeRange_part2(rLower, rUpper)
-		if err != nil {
-			log.Fatalf("Error analyzing range %v: %v", r, err)
-		}
-		prevResult := result
-		result += rangeResult
-		if result < prevResult {
-			log.Fatalf("Went down")
+	ranges := readMergedRanges(10)
+	result := new(big.Int)
+	for i, r := range ranges {
+		fmt.Printf("Range # %3v [%12v - %12v]\n", i, r.lower, r.upper)
+		for id := range repeatedIDs(r.lower, r.upper, repeatCounts) {
+			fmt.Printf("%12v = %v x %v\n", id.ID, id.Block, id.Repeats)
+			result.Add(result, id.ID)
 		}
 	}
-	log.Printf("Result: %v\n", result)
+	fmt.Printf("Result: %v\n", result)
 }
 
-func main_part1() {
-	scanner := bufio.NewScanner(os.Stdin)
-	var allRanges string
-	if scanner.Scan() {
-		allRanges = scanner.Text()
+func main_pattern(p Pattern, base int, workers int) {
+	ranges := readMergedRanges(base)
+	results, err := analyzeParallel(ranges, workers, func(r IDRange) ([2]*big.Int, error) {
+		count, sum, err := countAndSumRange(r.lower, r.upper, p, base)
+		return [2]*big.Int{count, sum}, err
+	})
+	if err != nil {
+		log.Fatalf("Failure: %v", err)
 	}
-	ranges := strings.Split(allRanges, ",")
-	result := int64(0)
-	for i := range ranges {
-		r := ranges[i]
-		log.Printf("Range # %v.2 %v", i, r)
-		rSplit := strings.Split(r, "-")
-		rLower := rSplit[0]
-		rUpper := rSplit[1]
-		rangeResult, err := analyzeRange(rLower, rUpper)
-		if err != nil {
-			log.Fatalf("Error analyzing range %v: %v", r, err)
+	totalCount := new(big.Int)
+	totalSum := new(big.Int)
+	for i, result := range results {
+		count, sum := result[0], result[1]
+		log.Printf("Range # %3v [%12v - %12v] count: %v, sum: %v", i, ranges[i].lower.Text(base), ranges[i].upper.Text(base), count, sum.Text(base))
+		totalCount.Add(totalCount, count)
+		totalSum.Add(totalSum, sum)
+	}
+	fmt.Printf("Count: %v\n", totalCount)
+	if base == 10 {
+		fmt.Printf("Sum: %v\n", totalSum)
+	} else {
+		fmt.Printf("Sum: %v (base %v), %v (base 10)\n", totalSum.Text(base), base, totalSum)
+	}
+}
+
+// main_parts prints the part 1 and part 2 answers, or only one of them if
+// part is 1 or 2.
+func main_parts(part int, workers int) {
+	ranges := readMergedRanges(10)
+	results, err := analyzeParallel(ranges, workers, func(r IDRange) ([2]*big.Int, error) {
+		var part1, part2 *big.Int
+		var err error
+		if part != 2 {
+			part1, err = r.Part1()
+			if err != nil {
+				return [2]*big.Int{}, err
+			}
 		}
-		prevResult := result
-		result += rangeResult
-		if result < prevResult {
-			log.Fatalf("Went down")
+		if part != 1 {
+			part2, err = r.Part2()
+			if err != nil {
+				return [2]*big.Int{}, err
+			}
 		}
+		return [2]*big.Int{part1, part2}, nil
+	})
+	if err != nil {
+		log.Fatalf("Failure: %v", err)
+	}
+	totals := [2]*big.Int{new(big.Int), new(big.Int)}
+	for i, result := range results {
+		computed := make([]string, 0, len(result))
+		for j := range totals {
+			if result[j] != nil {
+				totals[j].Add(totals[j], result[j])
+				computed = append(computed, result[j].String())
+			}
+		}
+		log.Printf("Range # %3v [%12v - %12v] %v", i, ranges[i].lower, ranges[i].upper, strings.Join(computed, " "))
+	}
+	if part != 2 {
+		fmt.Printf("Part 1 result: %v\n", totals[0])
+	}
+	if part != 1 {
+		fmt.Printf("Part 2 result: %v\n", totals[1])
 	}
-	log.Printf("Result: %v\n", result)
 }
 
 func analyzeNumber(num int) (int64, error) {
@@ -93,250 +143,178 @@ func analyzeNumber(num int) (int64, error) {
 	return 0, nil
 }
 
-func analyzeRange_part2(lower, upper string) (int64, error) {
+func analyzeRange_part2(lower, upper string) (*big.Int, error) {
 	/*
-		Because we don't can't analytically solve this case, we will simply iterate every number in every range
-	*/
-	lowerNum, err := strconv.Atoi(lower)
-	if err != nil {
-		return 0, err
-	}
-	upperNum, err := strconv.Atoi(upper)
-	if err != nil {
-		return 0, err
-	}
-	results := int64(0)
-	for i := lowerNum; i <= upperNum; i++ {
-		numResult, err := analyzeNumber(i)
-		if err != nil {
-			return 0, err
-		}
-		results += numResult
-	}
-	return results, nil
-}
-
-func analyzeRange(lower, upper string) (int64, error) {
-	/*
-		For 2 numbers composed of digits "A1", "B1", ...; and "A2", "B2", ... of length m, there is a formula to compute the number of repeating patterns between them:
-		Assume each number is broken into halves, H1_1 and H1_2, and H2_1 and H2_2
-		The formula is this:
-		(H2_1 - H1_1 - 1) + (1 if H1_1 > H1_2 else 0) + (1 if H2_2 > H2_1 else 0)
-
-		For ranges crossing different lengths - compute for all of every in between length.
-		For a given length, the total number for the entire range of that length is 10^(m / 2) where m is the length of the number. Well, not quite:
-		m = 4
-		1000 - 9999 -> 1010, 1111, 1212, 1313, ..., 2020, 2121, ..., 9898, 9999
-		Notice, we are missing 0-9 from 10^2. So it's actually 10^2 - 10^1. What about l = 6?
-		m = 6
-		100000 - 999999 -> 100100, 101101, ..., 199199, 200200, ..., 901901, ..., 999999.
-		So it's 10^3 - 10^2 == 9 * 10^2. So the final formula is: 10^((m / 2) - 1) * 9
-		Note m is always even, because odd m's don't have twice repeated patterns
+		A number of length m is invalid if it is some block of length m / k repeated k >= 2 times. Call S(k) the sum of
+		every number in the range of length m made of a block repeated exactly k times (computeBetweenOfSameLength).
+		The sets behind S(k) overlap: 111111 is 1 repeated 6 times, 11 repeated 3 times and 111 repeated twice.
+		Any number repeating with k1 and k2 also repeats with lcm(k1, k2), so inclusion-exclusion over the prime factors
+		of m gives the union:
+		sum = S(p1) + S(p2) + ... - S(p1 * p2) - ... + S(p1 * p2 * p3) ...
+		which is -mu(k) * S(k) summed over every divisor k > 1 of m, where mu is the Mobius function.
 
-		So the final algorithm is:
-		1. Sanitize the input - discard the lower range if it's odd, discard the upper range if it's odd
-		2. If both lower and upper and are the same length, compute using the in range formula
-		3. If both lower and upper are different lengths:
-		3.1 Compute the number between the lower range and the length above it
-		3.2 Compute the number between the upper range and the length below it
-		3.3 Compute the number in all ranges between the lower and upper lengths
+		For ranges crossing different lengths, split the range at every power of 10 and sum each length on its own.
 	*/
-
-	// Sanitize inputs
-	lower = sanitizeLower(lower)
-	upper = sanitizeUpper(upper)
-	log.Printf("lower: %v, upper: %v\n", lower, upper)
-
-	// Guard invalid range (may have originally been valid, but invalid after sanitization)
-	lowerNum, err := strconv.Atoi(lower)
+	lowerNum, err := parseBig(lower)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	upperNum, err := strconv.Atoi(upper)
+	upperNum, err := parseBig(upper)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	if upperNum <= lowerNum {
-		return 0, nil
+	results := new(big.Int)
+	if upperNum.Cmp(lowerNum) < 0 {
+		return results, nil
 	}
-
-	// Determine case:
-	lowerM := len(lower)
-	upperM := len(upper)
-	if lowerM == upperM {
-		result, err := computeBetweenOfSameLength(lower, upper)
-		if err != nil {
-			return 0, err
-		}
-		return int64(result), nil
-	} else {
-		lowersUpper := strings.Repeat("9", lowerM)
-		lowerResult, err := computeBetweenOfSameLength(lower, lowersUpper)
-		if err != nil {
-			return 0, err
-		}
-		uppersLower := "1" + strings.Repeat("0", upperM-1)
-		upperResult, err := computeBetweenOfSameLength(uppersLower, upper)
-		if err != nil {
-			return 0, err
-		}
-		nextLowestRange := lowerM + 2
-		nextHighestRange := upperM - 2
-		betweenResult := int64(0)
-		if nextLowestRange <= nextHighestRange {
-			betweenResult, err = computeEntiretyOfLengths(nextLowestRange, nextHighestRange)
+	for m := len(lowerNum.String()); m <= len(upperNum.String()); m++ {
+		mLower := maxBig(lowerNum, getLowerOfMNum(m))
+		mUpper := minBig(upperNum, getUpperOfMNum(m))
+		for k := 2; k <= m; k++ {
+			if m%k != 0 {
+				continue
+			}
+			mu := mobius(k)
+			if mu == 0 {
+				continue
+			}
+			kResult, err := computeBetweenOfSameLength(mLower.String(), mUpper.String(), k)
 			if err != nil {
-				return 0, err
+				return nil, err
 			}
+			kResult.Mul(kResult, big.NewInt(int64(-mu)))
+			results.Add(results, kResult)
 		}
-
-		fmt.Printf("Lower: %v\n", lower)
-		fmt.Printf("Upper: %v\n", upper)
-		fmt.Printf("lowersUpper: %v\n", lowersUpper)
-		fmt.Printf("uppersLower: %v\n", uppersLower)
-		fmt.Printf("nextLowestRange: %v\n", nextLowestRange)
-		fmt.Printf("nextHighestRange: %v\n", nextHighestRange)
-		fmt.Printf("lowerResult: %v\n", lowerResult)
-		fmt.Printf("upperResult: %v\n", upperResult)
-		fmt.Printf("betweenResult: %v\n", betweenResult)
-		return int64(lowerResult + upperResult + betweenResult), nil
 	}
+	return results, nil
 }
 
-func sanitizeLower(lower string) string {
-	if len(lower)%2 == 1 {
-		// odd numbered length - can't possibly have a twice repeated pattern
-		// so go to the beginning of the range at the next highest length
-		lowerLength := len(lower)
-		newLower := "1" + strings.Repeat("0", lowerLength)
-		lower = newLower
+// mobius returns the Mobius function of n: 0 if n has a squared prime factor,
+// otherwise 1 or -1 for an even or odd number of prime factors.
+func mobius(n int) int {
+	result := 1
+	for p := 2; p*p <= n; p++ {
+		if n%p != 0 {
+			continue
+		}
+		n /= p
+		if n%p == 0 {
+			return 0
+		}
+		result = -result
 	}
-	return lower
-}
-
-func sanitizeUpper(upper string) string {
-	if len(upper)%2 == 1 {
-		// odd numbered length - can't possibly have a twice repeated pattern
-		// so go to the end of the range at the next lowest length
-		upperLength := len(upper)
-		newUpper := strings.Repeat("9", upperLength-1)
-		upper = newUpper
+	if n > 1 {
+		result = -result
 	}
-	return upper
+	return result
 }
 
-func computeBetweenOfSameLength(lower, upper string) (int64, error) {
+func computeBetweenOfSameLength(lower, upper string, repeats int) (*big.Int, error) {
+	/*
+		Every number of length m made of a block repeated k times is block * M, where M is 1 followed by
+		(m / k - 1) zeros, repeated k times (k = 3, m = 6 -> M = 10101). So the matches between lower and upper are
+		exactly the blocks between ceil(lower / M) and floor(upper / M), clamped to blocks of length m / k, and their
+		sum is an arithmetic series: (first + last) * count / 2.
+	*/
 	lowerM := len(lower)
 	upperM := len(upper)
 	if lowerM != upperM {
-		return 0, errors.New("lower and upper must be the same length")
+		return nil, errors.New("lower and upper must be the same length")
 	}
-	lowerH1 := lower[:lowerM/2]
-	lowerH2 := lower[lowerM/2:]
-	upperH1 := upper[:upperM/2]
-	upperH2 := upper[upperM/2:]
-
-	lowerH1Num, err := strconv.Atoi(lowerH1)
+	if repeats < 1 || lowerM%repeats != 0 {
+		return new(big.Int), nil
+	}
+	lowerNum, err := parseBig(lower)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	lowerH2Num, err := strconv.Atoi(lowerH2)
+	upperNum, err := parseBig(upper)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	lowestBlock, highestBlock, _ := getBlockBounds(lowerNum, upperNum, lowerM/repeats, repeats, 10)
+	if highestBlock.Cmp(lowestBlock) < 0 {
+		return new(big.Int), nil
 	}
-	upperH1Num, err := strconv.Atoi(upperH1)
+	first, err := getRepeatedNum(lowestBlock, repeats)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	upperH2Num, err := strconv.Atoi(upperH2)
+	last, err := getRepeatedNum(highestBlock, repeats)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	lowestCrosses := lowerH2Num <= lowerH1Num
-	upperCrosses := upperH1Num <= upperH2Num
+	count := new(big.Int).Sub(highestBlock, lowestBlock)
+	count.Add(count, big.NewInt(1))
+	result := new(big.Int).Add(first, last)
+	result.Mul(result, count)
+	return result.Rsh(result, 1), nil
+}
 
-	// Debug output
-	fmt.Printf("L H1: %v\n", lowerH1)
-	fmt.Printf("L H2: %v\n", lowerH2)
-	fmt.Printf("U H1: %v\n", upperH1)
-	fmt.Printf("U H2: %v\n", upperH2)
-	fmt.Printf("lowestCrosses: %v\n", lowestCrosses)
-	fmt.Printf("upperCrosses: %v\n", upperCrosses)
-	result := int64(0)
-	for i := lowerH1Num + 1; i < upperH1Num; i++ {
-		numResult, err := getRepeatedNum(i)
-		if err != nil {
-			return 0, err
-		}
-		result += numResult
+// getBlockBounds returns the lowest and highest blocks of length blockLen
+// whose repetition lies in [lower, upper], along with M, the number a block
+// is multiplied by to repeat it. lower and upper must be of length
+// blockLen * repeats in the given base. If nothing matches, highest is below
+// lowest.
+func getBlockBounds(lower, upper *big.Int, blockLen, repeats, base int) (*big.Int, *big.Int, *big.Int) {
+	// M is 1 followed by blockLen - 1 zeros, repeated, so it's the sum of base^(i * blockLen)
+	multiplier := new(big.Int)
+	shift := getLowerOfMInBase(blockLen+1, base)
+	for i := 0; i < repeats; i++ {
+		multiplier.Mul(multiplier, shift)
+		multiplier.Add(multiplier, big.NewInt(1))
 	}
-	fmt.Printf("result: %v\n", result)
 
-	if lowerH1 == upperH1 && lowestCrosses && upperCrosses {
-		// They both cross, but are the same. Result = 1 in this case
-		lowestNum, err := getRepeatedNum(lowerH1Num)
-		if err != nil {
-			return 0, err
-		}
-		result += lowestNum
-	} else if lowestCrosses && lowerH1 != upperH1 {
-		// The lowest crosses and upper is a different prefix. Result ++
-		lowestNum, err := getRepeatedNum(lowerH1Num)
-		if err != nil {
-			return 0, err
-		}
-		result += lowestNum
-	}
-	if upperCrosses && lowerH1 != upperH1 {
-		// The "upper cross" case is already covered above if lowerH1 == upperH1
-		highestNum, err := getRepeatedNum(upperH1Num)
-		if err != nil {
-			return 0, err
-		}
-		result += highestNum
-	}
-	fmt.Printf("final result: %v\n", result)
+	lowestBlock := new(big.Int).Add(lower, multiplier)
+	lowestBlock.Sub(lowestBlock, big.NewInt(1))
+	lowestBlock.Quo(lowestBlock, multiplier)
+	lowestBlock = maxBig(lowestBlock, getLowerOfMInBase(blockLen, base))
+	highestBlock := new(big.Int).Quo(upper, multiplier)
+	highestBlock = minBig(highestBlock, getUpperOfMInBase(blockLen, base))
+	return lowestBlock, highestBlock, multiplier
+}
 
-	return result, nil
+func getRepeatedNum(block *big.Int, repeats int) (*big.Int, error) {
+	blockStr := block.String()
+	wholeStr := strings.Repeat(blockStr, repeats)
+	return parseBig(wholeStr)
 }
 
-func getRepeatedNum(half int) (int64, error) {
-	halfStr := strconv.Itoa(half)
-	wholeStr := halfStr + halfStr
-	whole, err := strconv.Atoi(wholeStr)
-	if err != nil {
-		return 0, err
-	}
-	return int64(whole), nil
+func getLowerOfMNum(m int) *big.Int {
+	return getLowerOfMInBase(m, 10)
 }
 
-func computeEntiretyOfLengths(lowerM, upperM int) (int64, error) {
-	if lowerM%2 == 1 {
-		return 0, errors.New("Lower length is odd")
-	}
-	if upperM%2 == 1 {
-		return 0, errors.New("Upper length is odd")
-	}
+func getUpperOfMNum(m int) *big.Int {
+	return getUpperOfMInBase(m, 10)
+}
 
-	result := int64(0)
-	fmt.Printf("lowerM: %v\n", lowerM)
-	fmt.Printf("upperM: %v\n", upperM)
-	for m := lowerM; m <= upperM; m += 2 {
-		lower := getLowerOfM(m)
-		upper := getUpperOfM(m)
-		mResult, err := computeBetweenOfSameLength(lower, upper)
-		if err != nil {
-			return 0, err
-		}
-		result += mResult
-	}
+func getLowerOfMInBase(m, base int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(int64(base)), big.NewInt(int64(m-1)), nil)
+}
 
-	return result, nil
+func getUpperOfMInBase(m, base int) *big.Int {
+	upper := getLowerOfMInBase(m+1, base)
+	return upper.Sub(upper, big.NewInt(1))
 }
 
-func getLowerOfM(m int) string {
-	return "1" + strings.Repeat("0", m-1)
+func parseBig(numStr string) (*big.Int, error) {
+	num, ok := new(big.Int).SetString(numStr, 10)
+	if !ok {
+		return nil, fmt.Errorf("Invalid number %q", numStr)
+	}
+	return num, nil
 }
-func getUpperOfM(m int) string {
-	return strings.Repeat("9", m)
+
+func maxBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+func minBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
 }
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"io"
+	"iter"
+	"log"
+	"math/big"
+	"strconv"
+	"testing"
+)
+
+func bruteForce_part2(lower, upper int) int64 {
+	result := int64(0)
+	for i := lower; i <= upper; i++ {
+		numResult, _ := analyzeNumber(i)
+		result += numResult
+	}
+	return result
+}
+
+func Test_analyzeRange_part2(t *testing.T) {
+	log.SetOutput(io.Discard)
+	data := []struct {
+		name  string
+		lower int
+		upper int
+	}{
+		{"single_digits", 1, 9},
+		{"two_digits", 1, 99},
+		{"crosses_lengths", 95, 1012},
+		{"six_digits", 100000, 999999},
+		{"overlapping_repeats", 111100, 111200},
+		{"empty", 1234, 1234},
+		{"exact_match", 121212, 121212},
+		{"wide", 1, 2000000},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			expected := bruteForce_part2(d.lower, d.upper)
+			result, err := analyzeRange_part2(strconv.Itoa(d.lower), strconv.Itoa(d.upper))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.Int64() != expected {
+				t.Errorf("Expected %v, got %v", expected, result)
+			}
+		})
+	}
+}
+
+func Test_analyzeRange_part2_big(t *testing.T) {
+	log.SetOutput(io.Discard)
+	data := []struct {
+		name     string
+		lower    string
+		upper    string
+		expected string
+	}{
+		{"repeated_ones", "1111111111111111111111111111111", "1111111111111111111111111111111", "1111111111111111111111111111111"},
+		{"prime_length_not_ones", "1111111111111111111111111111112", "2222222222222222222222222222221", "0"},
+		{"block_repeated_twice", "123456789012345123456789012345", "123456789012345123456789012345", "123456789012345123456789012345"},
+		{"one_match", "121212121212121212121212121211", "121212121212121212121212121213", "121212121212121212121212121212"},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			result, err := analyzeRange_part2(d.lower, d.upper)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.String() != d.expected {
+				t.Errorf("Expected %v, got %v", d.expected, result)
+			}
+		})
+	}
+}
+
+func Test_repeatedIDs(t *testing.T) {
+	log.SetOutput(io.Discard)
+	data := []struct {
+		name  string
+		lower int
+		upper int
+	}{
+		{"two_digits", 1, 99},
+		{"crosses_lengths", 95, 1012},
+		{"overlapping_repeats", 111100, 111200},
+		{"wide", 1, 2000000},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			expected := make([]int64, 0)
+			for i := d.lower; i <= d.upper; i++ {
+				if num, _ := analyzeNumber(i); num != 0 {
+					expected = append(expected, num)
+				}
+			}
+			ids := make([]RepeatedID, 0)
+			for id := range repeatedIDs(big.NewInt(int64(d.lower)), big.NewInt(int64(d.upper)), repeatCountsPart2) {
+				ids = append(ids, id)
+			}
+			if len(ids) != len(expected) {
+				t.Fatalf("Expected %v IDs, got %v", len(expected), len(ids))
+			}
+			for i, id := range ids {
+				if id.ID.Int64() != expected[i] {
+					t.Fatalf("Expected ID %v at %v, got %v", expected[i], i, id.ID)
+				}
+				repeated, _ := getRepeatedNum(id.Block, id.Repeats)
+				if repeated.Cmp(id.ID) != 0 {
+					t.Errorf("%v repeated %v times is not %v", id.Block, id.Repeats, id.ID)
+				}
+			}
+		})
+	}
+}
+
+// Test_repeatedIDs_block checks that Block is the shortest among the allowed
+// repeat counts rather than overall.
+func Test_repeatedIDs_block(t *testing.T) {
+	data := []struct {
+		name         string
+		repeatCounts func(m int) []int
+		block        int64
+		repeats      int
+	}{
+		{"part1", repeatCountsPart1, 11, 2},
+		{"part2", repeatCountsPart2, 1, 4},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			ids := make([]RepeatedID, 0)
+			for id := range repeatedIDs(big.NewInt(1111), big.NewInt(1111), d.repeatCounts) {
+				ids = append(ids, id)
+			}
+			if len(ids) != 1 || ids[0].Block.Int64() != d.block || ids[0].Repeats != d.repeats {
+				t.Errorf("Expected %v repeated %v times, got %v", d.block, d.repeats, ids)
+			}
+		})
+	}
+}
+
+// sumIDs adds up the IDs yielded by repeatedIDs.
+func sumIDs(ids iter.Seq[RepeatedID]) *big.Int {
+	sum := new(big.Int)
+	for id := range ids {
+		sum.Add(sum, id.ID)
+	}
+	return sum
+}
+
+// Test_repeatedIDs_sums checks the iterator against the closed forms.
+func Test_repeatedIDs_sums(t *testing.T) {
+	log.SetOutput(io.Discard)
+	data := []struct {
+		name  string
+		lower string
+		upper string
+	}{
+		{"example", "1188511880", "1188511890"},
+		{"crosses_lengths", "95", "1012"},
+		{"billions", "1000000000", "9999999999"},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			lower, _ := parseBig(d.lower)
+			upper, _ := parseBig(d.upper)
+			part1, _ := NewIDRange(lower, upper).Part1()
+			if sum := sumIDs(repeatedIDs(lower, upper, repeatCountsPart1)); sum.Cmp(part1) != 0 {
+				t.Errorf("Part 1: expected %v, got %v", part1, sum)
+			}
+			part2, _ := analyzeRange_part2(d.lower, d.upper)
+			if sum := sumIDs(repeatedIDs(lower, upper, repeatCountsPart2)); sum.Cmp(part2) != 0 {
+				t.Errorf("Part 2: expected %v, got %v", part2, sum)
+			}
+		})
+	}
+}
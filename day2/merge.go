@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// mergedBounds is a range built from one or more overlapping input ranges.
+// sources holds the positions of those ranges in the input.
+type mergedBounds struct {
+	IDRange
+	sources []int
+}
+
+func readRanges(scanner *bufio.Scanner, base int) ([]IDRange, error) {
+	var allRanges string
+	if scanner.Scan() {
+		allRanges = scanner.Text()
+	}
+	rangeStrs := strings.Split(allRanges, ",")
+	ranges := make([]IDRange, len(rangeStrs))
+	for i, r := range rangeStrs {
+		idRange, err := ParseIDRange(r, base)
+		if err != nil {
+			return nil, fmt.Errorf("Range # %v: %v", i, err)
+		}
+		ranges[i] = idRange
+	}
+	return ranges, nil
+}
+
+// mergeRanges sorts the ranges and merges every group of overlapping ones,
+// so each ID is analyzed once.
+func mergeRanges(ranges []IDRange) []mergedBounds {
+	order := make([]int, len(ranges))
+	for i := range ranges {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return ranges[a].lower.Cmp(ranges[b].lower)
+	})
+	merged := make([]mergedBounds, 0)
+	for _, i := range order {
+		r := ranges[i]
+		last := len(merged) - 1
+		if last >= 0 && r.lower.Cmp(merged[last].upper) <= 0 {
+			merged[last].upper = maxBig(merged[last].upper, r.upper)
+			merged[last].sources = append(merged[last].sources, i)
+			continue
+		}
+		merged = append(merged, mergedBounds{IDRange: r, sources: []int{i}})
+	}
+	return merged
+}
+
+// readMergedRanges reads the ranges from stdin, merges them, and logs every
+// merge that happened.
+func readMergedRanges(base int) []IDRange {
+	ranges, err := readRanges(bufio.NewScanner(os.Stdin), base)
+	if err != nil {
+		log.Fatalf("Failure: %v", err)
+	}
+	merged := mergeRanges(ranges)
+	result := make([]IDRange, len(merged))
+	for i, m := range merged {
+		result[i] = m.IDRange
+		if len(m.sources) == 1 {
+			continue
+		}
+		parts := make([]string, len(m.sources))
+		for j, source := range m.sources {
+			parts[j] = fmt.Sprintf("#%v [%v - %v]", source, ranges[source].lower.Text(base), ranges[source].upper.Text(base))
+		}
+		log.Printf("Merged ranges %v into [%v - %v]", strings.Join(parts, ", "), m.lower.Text(base), m.upper.Text(base))
+	}
+	if len(merged) < len(ranges) {
+		log.Printf("Merged %v ranges into %v", len(ranges), len(merged))
+	}
+	return result
+}
+
+// analyzeParallel runs analyze over every range on a pool of workers. The
+// results come back in the same order as the ranges, so aggregating them
+// does not depend on scheduling. The error of the earliest failing range is
+// returned.
+func analyzeParallel[T any](ranges []IDRange, workers int, analyze func(IDRange) (T, error)) ([]T, error) {
+	results := make([]T, len(ranges))
+	errs := make([]error, len(ranges))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], errs[i] = analyze(ranges[i])
+			}
+		}()
+	}
+	for i := range ranges {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+)
+
+func Test_mergeRanges(t *testing.T) {
+	ranges := []IDRange{
+		NewIDRange(big.NewInt(20), big.NewInt(25)),
+		NewIDRange(big.NewInt(95), big.NewInt(115)),
+		NewIDRange(big.NewInt(11), big.NewInt(22)),
+		NewIDRange(big.NewInt(15), big.NewInt(30)),
+		NewIDRange(big.NewInt(31), big.NewInt(40)),
+		NewIDRange(big.NewInt(95), big.NewInt(115)),
+	}
+	expected := []struct {
+		lower   int64
+		upper   int64
+		sources []int
+	}{
+		{11, 30, []int{2, 3, 0}},
+		{31, 40, []int{4}},
+		{95, 115, []int{1, 5}},
+	}
+	merged := mergeRanges(ranges)
+	if len(merged) != len(expected) {
+		t.Fatalf("Expected %v ranges, got %v", len(expected), len(merged))
+	}
+	for i, e := range expected {
+		m := merged[i]
+		if m.lower.Int64() != e.lower || m.upper.Int64() != e.upper || !slices.Equal(m.sources, e.sources) {
+			t.Errorf("Expected [%v - %v] from %v, got [%v - %v] from %v", e.lower, e.upper, e.sources, m.lower, m.upper, m.sources)
+		}
+	}
+}
+
+func Test_analyzeParallel(t *testing.T) {
+	ranges := make([]IDRange, 100)
+	for i := range ranges {
+		ranges[i] = NewIDRange(big.NewInt(int64(i)), big.NewInt(int64(i*2)))
+	}
+	results, err := analyzeParallel(ranges, 8, func(r IDRange) (int64, error) {
+		return r.upper.Int64() - r.lower.Int64(), nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i, result := range results {
+		if result != int64(i) {
+			t.Errorf("Expected %v at %v, got %v", i, i, result)
+		}
+	}
+
+	_, err = analyzeParallel(ranges, 8, func(r IDRange) (int64, error) {
+		if r.lower.Int64()%10 == 3 {
+			return 0, errors.New(r.lower.String())
+		}
+		return 0, nil
+	})
+	if err == nil || err.Error() != "3" {
+		t.Errorf("Expected error from range 3, got %v", err)
+	}
+}
@@ -1,45 +1,108 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
-	"log"
-	"os"
+	"math/big"
 	"strings"
 )
 
-func main_1() {
-	scanner := bufio.NewScanner(os.Stdin)
-	var allRanges string
-	if scanner.Scan() {
-		allRanges = scanner.Text()
+// IDRange is an inclusive range of IDs, parsed from "lower-upper".
+type IDRange struct {
+	lower *big.Int
+	upper *big.Int
+}
+
+// ParseIDRange parses and validates a range written in the given base. Both
+// bounds must be non-empty numbers without leading zeros, and lower must not
+// be above upper.
+func ParseIDRange(r string, base int) (IDRange, error) {
+	rSplit := strings.Split(r, "-")
+	lowerStr, upperStr, err := getLowerAndUpper(rSplit)
+	if err != nil {
+		return IDRange{}, fmt.Errorf("Invalid range %q: %v", r, err)
+	}
+	lower, err := parseBound(lowerStr, base)
+	if err != nil {
+		return IDRange{}, fmt.Errorf("Invalid range %q: lower bound: %v", r, err)
+	}
+	upper, err := parseBound(upperStr, base)
+	if err != nil {
+		return IDRange{}, fmt.Errorf("Invalid range %q: upper bound: %v", r, err)
+	}
+	if upper.Cmp(lower) < 0 {
+		return IDRange{}, fmt.Errorf("Invalid range %q: lower bound is above upper bound", r)
 	}
-	ranges := strings.Split(allRanges, ",")
+	return IDRange{lower: lower, upper: upper}, nil
+}
 
-	result := 0
-	for i := range ranges {
-		r := ranges[i]
-		rSplit := strings.Split(r, "-")
-		rangeResult, err := analyzeRange_1(rSplit)
+func NewIDRange(lower, upper *big.Int) IDRange {
+	return IDRange{lower: lower, upper: upper}
+}
+
+func (r IDRange) String() string {
+	return fmt.Sprintf("%v-%v", r.lower, r.upper)
+}
+
+// Part1 returns the sum of the IDs in the range made of a block repeated
+// exactly twice. The range is split by length as in analyzeRange_part2, and
+// only even lengths can hold such IDs.
+func (r IDRange) Part1() (*big.Int, error) {
+	result := new(big.Int)
+	for m := len(r.lower.String()); m <= len(r.upper.String()); m++ {
+		if m%2 != 0 {
+			continue
+		}
+		mLower := maxBig(r.lower, getLowerOfMNum(m))
+		mUpper := minBig(r.upper, getUpperOfMNum(m))
+		mResult, err := computeBetweenOfSameLength(mLower.String(), mUpper.String(), 2)
 		if err != nil {
-			log.Fatalf("Failure: %v", err)
+			return nil, err
 		}
-		result += rangeResult
+		result.Add(result, mResult)
 	}
+	return result, nil
+}
 
-	fmt.Printf("Result: %v", result)
+// Part2 returns the sum of the IDs in the range made of a block repeated at
+// least twice.
+func (r IDRange) Part2() (*big.Int, error) {
+	return analyzeRange_part2(r.lower.String(), r.upper.String())
 }
 
-func analyzeRange_1(r []string) (int, error) {
-	_, _, err := getLowerAndUpper(r)
-	if err != nil {
-		return 0, err
+func parseBound(bound string, base int) (*big.Int, error) {
+	if bound == "" {
+		return nil, fmt.Errorf("Bound is empty")
+	}
+	for i, c := range bound {
+		if digitValue(c) >= base {
+			return nil, fmt.Errorf("Invalid character %q at position %v for base %v", c, i, base)
+		}
 	}
-	return 0, nil
+	if len(bound) > 1 && bound[0] == '0' {
+		return nil, fmt.Errorf("Bound %q has leading zeros", bound)
+	}
+	return parseBigInBase(bound, base)
+}
+
+// digitValue returns the value of a digit in bases up to 36, or 36 if c is
+// not a digit.
+func digitValue(c rune) int {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0')
+	case 'a' <= c && c <= 'z':
+		return int(c-'a') + 10
+	case 'A' <= c && c <= 'Z':
+		return int(c-'A') + 10
+	}
+	return 36
 }
 
 func validateRangeSlice(r []string) error {
 	rangeLen := len(r)
+	if rangeLen == 1 {
+		return fmt.Errorf("Range is missing '-'")
+	}
 	if rangeLen != 2 {
 		return fmt.Errorf("Range must have length 2. Has length %v", rangeLen)
 	}
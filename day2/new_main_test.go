@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+)
+
+func Test_ParseIDRange(t *testing.T) {
+	data := []struct {
+		name     string
+		r        string
+		base     int
+		expected string
+		errMsg   string
+	}{
+		{"valid", "11-22", 10, "11-22", ""},
+		{"single_id", "7-7", 10, "7-7", ""},
+		{"hex", "ff-1a0", 16, "255-416", ""},
+		{"reversed", "22-11", 10, "", `Invalid range "22-11": lower bound is above upper bound`},
+		{"leading_zeros", "011-22", 10, "", `Invalid range "011-22": lower bound: Bound "011" has leading zeros`},
+		{"non_digit", "11-2x", 10, "", `Invalid range "11-2x": upper bound: Invalid character 'x' at position 1 for base 10`},
+		{"digit_out_of_base", "11-29", 8, "", `Invalid range "11-29": upper bound: Invalid character '9' at position 1 for base 8`},
+		{"missing_dash", "1122", 10, "", `Invalid range "1122": Range is missing '-'`},
+		{"empty_bound", "-22", 10, "", `Invalid range "-22": lower bound: Bound is empty`},
+		{"too_many_parts", "1-2-3", 10, "", `Invalid range "1-2-3": Range must have length 2. Has length 3`},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			r, err := ParseIDRange(d.r, d.base)
+			var errMsg string
+			if err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != d.errMsg {
+				t.Fatalf("Expected %v, got %v", d.errMsg, errMsg)
+			}
+			if err == nil && r.String() != d.expected {
+				t.Errorf("Expected %v, got %v", d.expected, r)
+			}
+		})
+	}
+}
+
+func Test_IDRange_parts(t *testing.T) {
+	data := []struct {
+		name  string
+		r     string
+		part1 int64
+		part2 int64
+	}{
+		{"single_ids", "11-11", 11, 11},
+		{"single_id_not_repeated", "12-12", 0, 0},
+		{"one_digit", "1-9", 0, 0},
+		{"one_digit_to_two", "5-22", 33, 33},
+		{"odd_length", "100-999", 0, 111 + 222 + 333 + 444 + 555 + 666 + 777 + 888 + 999},
+		{"example", "1188511880-1188511890", 1188511885, 1188511885},
+		{"crosses_lengths", "95-115", 99, 99 + 111},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			r, err := ParseIDRange(d.r, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			part1, err := r.Part1()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if part1.Int64() != d.part1 {
+				t.Errorf("Part 1: expected %v, got %v", d.part1, part1)
+			}
+			part2, err := r.Part2()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if part2.Int64() != d.part2 {
+				t.Errorf("Part 2: expected %v, got %v", d.part2, part2)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+// Pattern decides whether a number is an invalid ID. digits is the number
+// written in the base being analyzed, without leading zeros.
+type Pattern interface {
+	Matches(digits string) bool
+}
+
+// RepeatPattern is a Pattern that only depends on how many times a number's
+// shortest block repeats. Ranges can be summed in closed form for these.
+type RepeatPattern interface {
+	Pattern
+	// MatchesRepeats reports whether a number made of its shortest block
+	// repeated r times matches. r is 1 for numbers with no repetition.
+	MatchesRepeats(r int) bool
+}
+
+// ExactRepeats matches numbers made of a block repeated exactly K times,
+// e.g. K = 2 is part 1. 111111 matches K = 2, 3 and 6.
+type ExactRepeats struct {
+	K int
+}
+
+func (p ExactRepeats) MatchesRepeats(r int) bool {
+	return r%p.K == 0
+}
+
+func (p ExactRepeats) Matches(digits string) bool {
+	return p.MatchesRepeats(maximalRepeats(digits))
+}
+
+// AtLeastRepeats matches numbers made of a block repeated K or more times,
+// e.g. K = 2 is part 2.
+type AtLeastRepeats struct {
+	K int
+}
+
+func (p AtLeastRepeats) MatchesRepeats(r int) bool {
+	return r >= p.K
+}
+
+func (p AtLeastRepeats) Matches(digits string) bool {
+	return p.MatchesRepeats(maximalRepeats(digits))
+}
+
+type Palindrome struct{}
+
+func (p Palindrome) Matches(digits string) bool {
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		if digits[i] != digits[j] {
+			return false
+		}
+	}
+	return true
+}
+
+// DigitMultiset matches numbers whose digits are a rearrangement of a fixed
+// set of digits, e.g. 112 matches 112, 121 and 211.
+type DigitMultiset struct {
+	sorted string
+}
+
+func NewDigitMultiset(digits string) DigitMultiset {
+	return DigitMultiset{sorted: sortDigits(strings.ToLower(digits))}
+}
+
+func (p DigitMultiset) Matches(digits string) bool {
+	return len(digits) == len(p.sorted) && sortDigits(digits) == p.sorted
+}
+
+func sortDigits(digits string) string {
+	b := []byte(digits)
+	slices.Sort(b)
+	return string(b)
+}
+
+// maximalRepeats returns how many times the shortest block of digits repeats.
+func maximalRepeats(digits string) int {
+	m := len(digits)
+	for blockLen := 1; blockLen <= m/2; blockLen++ {
+		if m%blockLen != 0 {
+			continue
+		}
+		if strings.Repeat(digits[:blockLen], m/blockLen) == digits {
+			return m / blockLen
+		}
+	}
+	return 1
+}
+
+func parseBigInBase(numStr string, base int) (*big.Int, error) {
+	num, ok := new(big.Int).SetString(numStr, base)
+	if !ok {
+		return nil, fmt.Errorf("Invalid base %v number %q", base, numStr)
+	}
+	return num, nil
+}
+
+/*
+countAndSumRange returns how many numbers in [lower, upper] match p in the given base, and their sum.
+
+RepeatPatterns are solved per length m without visiting numbers. getBlockBounds gives S(k), the count and sum of
+numbers made of a block repeated k times, for every divisor k of m. Those sets nest: a number whose shortest block
+repeats r times is in S(k) for every k dividing r. So the numbers whose shortest block repeats exactly r times are
+P(r) = sum of mu(s / r) * S(s) over the multiples s of r dividing m (Mobius inversion), and the answer is the sum of
+P(r) over every r the pattern accepts.
+
+0 has no length to split by, so it is handled on its own: it is written "0", a single digit that repeats once, and
+matches when the pattern accepts r = 1 (ExactRepeats or AtLeastRepeats with K = 1).
+
+Any other Pattern falls back to checking every number in the range.
+*/
+func countAndSumRange(lower, upper *big.Int, p Pattern, base int) (*big.Int, *big.Int, error) {
+	if base < 2 || base > 36 {
+		return nil, nil, fmt.Errorf("Base must be between 2 and 36. Got %v", base)
+	}
+	if lower.Sign() < 0 {
+		return nil, nil, fmt.Errorf("Range must not contain negative numbers. Got %v", lower)
+	}
+	count := new(big.Int)
+	sum := new(big.Int)
+	if upper.Cmp(lower) < 0 {
+		return count, sum, nil
+	}
+	rp, ok := p.(RepeatPattern)
+	if !ok {
+		one := big.NewInt(1)
+		for num := new(big.Int).Set(lower); num.Cmp(upper) <= 0; num.Add(num, one) {
+			if p.Matches(num.Text(base)) {
+				count.Add(count, one)
+				sum.Add(sum, num)
+			}
+		}
+		return count, sum, nil
+	}
+
+	if lower.Sign() == 0 && rp.MatchesRepeats(1) {
+		count.Add(count, big.NewInt(1))
+	}
+	for m := len(lower.Text(base)); m <= len(upper.Text(base)); m++ {
+		mLower := maxBig(lower, getLowerOfMInBase(m, base))
+		mUpper := minBig(upper, getUpperOfMInBase(m, base))
+		divisors := make([]int, 0)
+		for k := 1; k <= m; k++ {
+			if m%k == 0 {
+				divisors = append(divisors, k)
+			}
+		}
+		kCounts := make(map[int]*big.Int)
+		kSums := make(map[int]*big.Int)
+		for _, k := range divisors {
+			kCounts[k], kSums[k] = countAndSumRepeats(mLower, mUpper, m/k, k, base)
+		}
+		for _, r := range divisors {
+			if !rp.MatchesRepeats(r) {
+				continue
+			}
+			for _, s := range divisors {
+				if s%r != 0 {
+					continue
+				}
+				mu := big.NewInt(int64(mobius(s / r)))
+				count.Add(count, new(big.Int).Mul(mu, kCounts[s]))
+				sum.Add(sum, new(big.Int).Mul(mu, kSums[s]))
+			}
+		}
+	}
+	return count, sum, nil
+}
+
+// countAndSumRepeats returns how many numbers in [lower, upper] are a block
+// of length blockLen repeated k times, and their sum.
+func countAndSumRepeats(lower, upper *big.Int, blockLen, k, base int) (*big.Int, *big.Int) {
+	lowestBlock, highestBlock, multiplier := getBlockBounds(lower, upper, blockLen, k, base)
+	count := new(big.Int).Sub(highestBlock, lowestBlock)
+	count.Add(count, big.NewInt(1))
+	if count.Sign() <= 0 {
+		return new(big.Int), new(big.Int)
+	}
+	// sum of the blocks is (lowest + highest) * count / 2, and every block is multiplied by M
+	sum := new(big.Int).Add(lowestBlock, highestBlock)
+	sum.Mul(sum, count)
+	sum.Rsh(sum, 1)
+	return count, sum.Mul(sum, multiplier)
+}
+
+func newPattern(name string, k int, digits string) (Pattern, error) {
+	switch name {
+	case "exact":
+		if k < 1 {
+			return nil, fmt.Errorf("Repeat count must be >= 1. Got %v", k)
+		}
+		return ExactRepeats{K: k}, nil
+	case "atleast":
+		if k < 1 {
+			return nil, fmt.Errorf("Repeat count must be >= 1. Got %v", k)
+		}
+		return AtLeastRepeats{K: k}, nil
+	case "palindrome":
+		return Palindrome{}, nil
+	case "multiset":
+		if digits == "" {
+			return nil, fmt.Errorf("The multiset pattern needs digits")
+		}
+		return NewDigitMultiset(digits), nil
+	}
+	return nil, fmt.Errorf("Unknown pattern %q. Expected exact, atleast, palindrome or multiset", name)
+}
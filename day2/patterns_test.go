@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+// noClosedForm hides the RepeatPattern methods so countAndSumRange checks every number
+type noClosedForm struct {
+	p Pattern
+}
+
+func (n noClosedForm) Matches(digits string) bool {
+	return n.p.Matches(digits)
+}
+
+func Test_countAndSumRange_closedForm(t *testing.T) {
+	patterns := []struct {
+		name string
+		p    Pattern
+	}{
+		{"exact_2", ExactRepeats{K: 2}},
+		{"exact_3", ExactRepeats{K: 3}},
+		{"atleast_2", AtLeastRepeats{K: 2}},
+		{"atleast_3", AtLeastRepeats{K: 3}},
+	}
+	bases := []int{2, 3, 10, 16, 36}
+	for _, d := range patterns {
+		for _, base := range bases {
+			t.Run(fmt.Sprintf("%v_base_%v", d.name, base), func(t *testing.T) {
+				lower, upper := big.NewInt(1), big.NewInt(200000)
+				count, sum, err := countAndSumRange(lower, upper, d.p, base)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				expectedCount, expectedSum, _ := countAndSumRange(lower, upper, noClosedForm{d.p}, base)
+				if count.Cmp(expectedCount) != 0 || sum.Cmp(expectedSum) != 0 {
+					t.Errorf("Base %v: expected %v / %v, got %v / %v", base, expectedCount, expectedSum, count, sum)
+				}
+			})
+		}
+	}
+}
+
+func Test_patterns(t *testing.T) {
+	data := []struct {
+		name     string
+		p        Pattern
+		digits   string
+		expected bool
+	}{
+		{"exact_twice", ExactRepeats{K: 2}, "123123", true},
+		{"exact_twice_of_six", ExactRepeats{K: 2}, "111111", true},
+		{"exact_twice_of_three", ExactRepeats{K: 2}, "121212", false},
+		{"atleast_three", AtLeastRepeats{K: 3}, "121212", true},
+		{"atleast_three_twice", AtLeastRepeats{K: 3}, "123123", false},
+		{"palindrome", Palindrome{}, "12321", true},
+		{"not_palindrome", Palindrome{}, "12312", false},
+		{"multiset", NewDigitMultiset("1122"), "2121", true},
+		{"multiset_wrong_count", NewDigitMultiset("1122"), "1112", false},
+		{"multiset_hex", NewDigitMultiset("AF"), "fa", true},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			if result := d.p.Matches(d.digits); result != d.expected {
+				t.Errorf("Expected %v, got %v", d.expected, result)
+			}
+		})
+	}
+}
+
+func Test_countAndSumRange_zero(t *testing.T) {
+	data := []struct {
+		name          string
+		p             RepeatPattern
+		lower         int64
+		upper         int64
+		expectedCount int64
+		expectedSum   int64
+	}{
+		{"exact_1_only_zero", ExactRepeats{K: 1}, 0, 0, 1, 0},
+		{"exact_1", ExactRepeats{K: 1}, 0, 100, 101, 5050},
+		{"atleast_1", AtLeastRepeats{K: 1}, 0, 100, 101, 5050},
+		{"exact_1_from_one", ExactRepeats{K: 1}, 1, 100, 100, 5050},
+		{"exact_2_only_zero", ExactRepeats{K: 2}, 0, 0, 0, 0},
+		{"atleast_2", AtLeastRepeats{K: 2}, 0, 100, 9, 495},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			lower, upper := big.NewInt(d.lower), big.NewInt(d.upper)
+			count, sum, err := countAndSumRange(lower, upper, d.p, 10)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if count.Int64() != d.expectedCount || sum.Int64() != d.expectedSum {
+				t.Errorf("Expected %v / %v, got %v / %v", d.expectedCount, d.expectedSum, count, sum)
+			}
+			bruteCount, bruteSum, _ := countAndSumRange(lower, upper, noClosedForm{d.p}, 10)
+			if count.Cmp(bruteCount) != 0 || sum.Cmp(bruteSum) != 0 {
+				t.Errorf("Brute force: expected %v / %v, got %v / %v", bruteCount, bruteSum, count, sum)
+			}
+		})
+	}
+}
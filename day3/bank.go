@@ -0,0 +1,63 @@
+package main
+
+import (
+	"log"
+	"maps"
+	"slices"
+)
+
+// InvalidBattery is a character in a bank that is not a digit. Column is
+// 1-based and counts characters, not bytes.
+type InvalidBattery struct {
+	Column int
+	Char   rune
+}
+
+// stripInvalid removes every non-digit from bank. It returns the cleaned
+// bank, the byte index in bank of every kept battery, and what was removed.
+func stripInvalid(bank string) (string, []int, []InvalidBattery) {
+	cleaned := make([]byte, 0, len(bank))
+	columns := make([]int, 0, len(bank))
+	invalid := make([]InvalidBattery, 0)
+	column := 0
+	for i, c := range bank {
+		column++
+		if c < '0' || c > '9' {
+			invalid = append(invalid, InvalidBattery{Column: column, Char: c})
+			continue
+		}
+		cleaned = append(cleaned, byte(c))
+		columns = append(columns, i)
+	}
+	return string(cleaned), columns, invalid
+}
+
+// handleBatteryBankLenient selects batteries like handleBatteryBank after
+// stripping invalid characters. The returned indices point into the original
+// bank.
+func handleBatteryBankLenient(bank string, n int, opts SelectOptions) (Selection, []InvalidBattery, error) {
+	cleaned, columns, invalid := stripInvalid(bank)
+	selection, err := handleBatteryBank(cleaned, n, opts)
+	if err != nil {
+		return Selection{}, invalid, err
+	}
+	for i, index := range selection.Indices {
+		selection.Indices[i] = columns[index]
+	}
+	return selection, invalid, nil
+}
+
+func printStrippedSummary(stripped map[int][]InvalidBattery) {
+	if len(stripped) == 0 {
+		return
+	}
+	total := 0
+	for _, bankNum := range slices.Sorted(maps.Keys(stripped)) {
+		invalid := stripped[bankNum]
+		total += len(invalid)
+		for _, b := range invalid {
+			log.Printf("Warning: bank %v, column %v: stripped invalid battery %q", bankNum, b.Column, b.Char)
+		}
+	}
+	log.Printf("Warning: stripped %v invalid characters from %v banks", total, len(stripped))
+}
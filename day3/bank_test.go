@@ -0,0 +1,64 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func Test_handleBatteryBank_invalid(t *testing.T) {
+	data := []struct {
+		name   string
+		bank   string
+		errMsg string
+	}{
+		{"letter", "12a45", "Invalid battery 'a' at column 3"},
+		{"space", " 1234", "Invalid battery ' ' at column 1"},
+		{"sign", "1234-", "Invalid battery '-' at column 5"},
+		{"multibyte", "1é234", "Invalid battery 'é' at column 2"},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			_, err := handleBatteryBank(d.bank, 2, SelectOptions{})
+			var errMsg string
+			if err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != d.errMsg {
+				t.Errorf("Expected %v, got %v", d.errMsg, errMsg)
+			}
+		})
+	}
+}
+
+func Test_handleBatteryBankLenient(t *testing.T) {
+	selection, invalid, err := handleBatteryBankLenient("1x9?8", 2, SelectOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if selection.Digits != "98" {
+		t.Errorf("Expected 98, got %v", selection.Digits)
+	}
+	if !slices.Equal(selection.Indices, []int{2, 4}) {
+		t.Errorf("Expected indices [2 4], got %v", selection.Indices)
+	}
+	expected := []InvalidBattery{{Column: 2, Char: 'x'}, {Column: 4, Char: '?'}}
+	if !slices.Equal(invalid, expected) {
+		t.Errorf("Expected %v, got %v", expected, invalid)
+	}
+	// columns count characters, so the x after é is in column 3 although it is byte 4
+	selection, invalid, err = handleBatteryBankLenient("é1x9", 2, SelectOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !slices.Equal(selection.Indices, []int{2, 4}) {
+		t.Errorf("Expected indices [2 4], got %v", selection.Indices)
+	}
+	expected = []InvalidBattery{{Column: 1, Char: 'é'}, {Column: 3, Char: 'x'}}
+	if !slices.Equal(invalid, expected) {
+		t.Errorf("Expected %v, got %v", expected, invalid)
+	}
+	_, err = getNum("1é2x", 4)
+	if err == nil || err.Error() != "Invalid battery 'x' at column 4" {
+		t.Errorf("Expected column 4, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+/*
+bankCurve returns the best joltage of a bank for every n from 1 to the bank length (index n - 1).
+
+Without constraints, the best selections are nested: the best n batteries are the best n + 1 with one battery
+removed, namely the first one that is beaten by its successor, or the last one if there is none. That is exactly
+the order in which the stack in handleBatteryBank pops batteries when it is allowed to skip everything, followed
+by popping what is left on the stack from the top. So one pass over the bank gives the step at which every
+battery gets removed, and the selection for n is every battery removed after step len - n. Going from n - 1 to n
+puts back a single battery, so every joltage is built from the previous one instead of from the digits.
+Constrained selections are not nested, so they are solved separately for every n, stopping at the first n the
+constraints can't fit.
+*/
+func bankCurve(bank string, opts SelectOptions) ([]*big.Int, error) {
+	bankLen := len(bank)
+	joltages := make([]*big.Int, bankLen)
+	digits := make([]byte, bankLen)
+	for i := 0; i < bankLen; i++ {
+		num, err := getNum(bank, i)
+		if err != nil {
+			return nil, err
+		}
+		digits[i] = byte('0' + num)
+	}
+	if opts.constrained() {
+		for n := 1; n <= bankLen; n++ {
+			selection, err := handleBatteryBank(bank, n, opts)
+			if err != nil {
+				// the constraints don't fit n batteries in this bank, so they won't fit more either
+				return joltages[:n-1], nil
+			}
+			joltages[n-1] = selection.Joltage
+		}
+		return joltages, nil
+	}
+
+	// removedBy[step] is the battery removed at that step (from 1)
+	removedBy := make([]int, bankLen+1)
+	step := 0
+	stack := make([]int, 0, bankLen)
+	for i := 0; i < bankLen; i++ {
+		for len(stack) > 0 && opts.better(digits[i], digits[stack[len(stack)-1]]) {
+			step++
+			removedBy[step] = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, i)
+	}
+	for len(stack) > 0 {
+		step++
+		removedBy[step] = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+	}
+
+	// the selection for n is the one for n - 1 with battery removedBy[len - n + 1] put back. With k chosen batteries
+	// after it, it splits the previous joltage J into high * 10^k + low and the new one is (high * 10 + digit) * 10^k
+	// + low. chosen is a Fenwick tree counting the chosen batteries up to an index, which gives k.
+	chosen := make([]int, bankLen+1)
+	ten := big.NewInt(10)
+	joltage := new(big.Int)
+	for n := 1; n <= bankLen; n++ {
+		i := removedBy[bankLen-n+1]
+		before := 0
+		for j := i + 1; j > 0; j -= j & -j {
+			before += chosen[j]
+		}
+		k := (n - 1) - before
+		pow := new(big.Int).Exp(ten, big.NewInt(int64(k)), nil)
+		high, low := new(big.Int).QuoRem(joltage, pow, new(big.Int))
+		high.Mul(high, ten)
+		high.Add(high, big.NewInt(int64(digits[i]-'0')))
+		high.Mul(high, pow)
+		joltage = high.Add(high, low)
+		joltages[n-1] = joltage
+		for j := i + 1; j <= bankLen; j += j & -j {
+			chosen[j]++
+		}
+	}
+	return joltages, nil
+}
+
+// JoltageCurve sums the joltage curves of many banks. For n above the length
+// of a bank's curve, the bank is left out of the total and counted as too
+// short.
+type JoltageCurve struct {
+	totals  []*big.Int
+	lengths []int
+}
+
+func NewJoltageCurve() *JoltageCurve {
+	return &JoltageCurve{totals: make([]*big.Int, 0), lengths: make([]int, 0)}
+}
+
+func (c *JoltageCurve) Add(joltages []*big.Int) {
+	for len(c.totals) < len(joltages) {
+		c.totals = append(c.totals, new(big.Int))
+	}
+	for i, joltage := range joltages {
+		c.totals[i].Add(c.totals[i], joltage)
+	}
+	c.lengths = append(c.lengths, len(joltages))
+}
+
+// Bottlenecks returns the largest n every bank supports and the banks
+// (1-based) that limit it.
+func (c *JoltageCurve) Bottlenecks() (int, []int) {
+	if len(c.lengths) == 0 {
+		return 0, nil
+	}
+	shortest := c.lengths[0]
+	for _, l := range c.lengths {
+		shortest = min(shortest, l)
+	}
+	return shortest, c.banksOfLength(shortest)
+}
+
+func (c *JoltageCurve) banksOfLength(length int) []int {
+	banks := make([]int, 0)
+	for i, l := range c.lengths {
+		if l == length {
+			banks = append(banks, i+1)
+		}
+	}
+	return banks
+}
+
+// WriteCSV writes one row per n. banks_too_short counts the banks left out
+// of the total, and new_bottlenecks lists the banks that drop out at that n.
+func (c *JoltageCurve) WriteCSV(out io.Writer) error {
+	w := csv.NewWriter(out)
+	err := w.Write([]string{"n", "joltage", "banks_used", "banks_too_short", "new_bottlenecks"})
+	if err != nil {
+		return err
+	}
+	tooShort := 0
+	for n := 1; n <= len(c.totals); n++ {
+		dropped := c.banksOfLength(n - 1)
+		tooShort += len(dropped)
+		droppedStrs := make([]string, len(dropped))
+		for i, bank := range dropped {
+			droppedStrs[i] = strconv.Itoa(bank)
+		}
+		err := w.Write([]string{
+			strconv.Itoa(n),
+			c.totals[n-1].String(),
+			strconv.Itoa(len(c.lengths) - tooShort),
+			strconv.Itoa(tooShort),
+			strings.Join(droppedStrs, " "),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("Writing curve: %v", err)
+	}
+	return nil
+}
@@ -2,88 +2,190 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"log"
+	"math/big"
 	"os"
-	"strconv"
+	"unicode/utf8"
 )
 
 func main() {
+	n := flag.Int("n", 12, "number of batteries to turn on in each bank")
+	highlight := flag.String("highlight", "", "print every bank with its chosen batteries highlighted: color or brackets")
+	jsonPath := flag.String("json", "", "write the chosen batteries of every bank as JSON to this file")
+	var opts SelectOptions
+	flag.BoolVar(&opts.Smallest, "smallest", false, "choose the smallest joltage instead of the largest")
+	flag.IntVar(&opts.MinGap, "min-gap", 0, "minimum number of unused batteries between two chosen ones")
+	flag.IntVar(&opts.SegmentLen, "segment-len", 0, "split every bank into segments of this many batteries (0 for one segment)")
+	flag.IntVar(&opts.SegmentCap, "segment-cap", 0, "maximum number of batteries chosen from each segment (0 for no cap)")
+	lenient := flag.Bool("lenient", false, "strip invalid characters from banks with a warning instead of failing")
+	curvePath := flag.String("curve", "", "write the total joltage for every n as CSV to this file (- for stdout)")
+	flag.Parse()
+	if *highlight != "" && *highlight != "color" && *highlight != "brackets" {
+		log.Fatalf("Failed: unknown highlight %q. Expected color or brackets", *highlight)
+	}
 	scanner := bufio.NewScanner(os.Stdin)
-	result := int64(0)
-	for scanner.Scan() {
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1<<30)
+	if *curvePath != "" {
+		main_curve(scanner, opts, *lenient, *curvePath)
+		return
+	}
+	result := new(big.Int)
+	reports := make([]BankReport, 0)
+	stripped := make(map[int][]InvalidBattery)
+	for bankNum := 1; scanner.Scan(); bankNum++ {
 		batteryBank := scanner.Text()
-		bankResult, err := handleBatteryBank(batteryBank, 12)
+		var selection Selection
+		var err error
+		if *lenient {
+			var invalid []InvalidBattery
+			selection, invalid, err = handleBatteryBankLenient(batteryBank, *n, opts)
+			if len(invalid) > 0 {
+				stripped[bankNum] = invalid
+			}
+		} else {
+			selection, err = handleBatteryBank(batteryBank, *n, opts)
+		}
+		if err != nil {
+			log.Fatalf("Failed: bank %v: %v", bankNum, err)
+		}
+		result.Add(result, selection.Joltage)
+		if *highlight != "" {
+			fmt.Printf("%v  %v\n", highlightBank(batteryBank, selection.Indices, *highlight == "color"), selection.Joltage)
+		}
+		if *jsonPath != "" {
+			reports = append(reports, newBankReport(bankNum, selection))
+		}
+	}
+	if *jsonPath != "" {
+		err := writeJSONReport(*jsonPath, reports)
 		if err != nil {
 			log.Fatalf("Failed: %v", err)
 		}
-		result += int64(bankResult)
 	}
+	printStrippedSummary(stripped)
 	log.Printf("Result: %v", result)
 }
 
-func handleBatteryBank(bank string, n int) (int64, error) {
-	const sentinel = -1
-	bankLen := len(bank)
-	if bankLen < n {
-		return 0, fmt.Errorf("Battery bank lenght needs to be >= %v. Given %v", n, bankLen)
-	}
-	highest := make([]int, n)
-	for i := 0; i < n; i++ {
-		highest[i] = sentinel
+func main_curve(scanner *bufio.Scanner, opts SelectOptions, lenient bool, curvePath string) {
+	curve := NewJoltageCurve()
+	stripped := make(map[int][]InvalidBattery)
+	for bankNum := 1; scanner.Scan(); bankNum++ {
+		batteryBank := scanner.Text()
+		if lenient {
+			var invalid []InvalidBattery
+			batteryBank, _, invalid = stripInvalid(batteryBank)
+			if len(invalid) > 0 {
+				stripped[bankNum] = invalid
+			}
+		}
+		joltages, err := bankCurve(batteryBank, opts)
+		if err != nil {
+			log.Fatalf("Failed: bank %v: %v", bankNum, err)
+		}
+		curve.Add(joltages)
 	}
-	for i := 0; i < bankLen; i++ {
-		num, err := getNum(bank, i)
+	printStrippedSummary(stripped)
+	out := os.Stdout
+	if curvePath != "-" {
+		f, err := os.Create(curvePath)
 		if err != nil {
-			return 0, err
+			log.Fatalf("Failed: %v", err)
 		}
-		firstSelectable := n - min(bankLen-i, n)
-		maybeSelect(highest, firstSelectable, num, sentinel)
+		defer f.Close()
+		out = f
 	}
-	joltage, err := makeNumber(highest)
+	err := curve.WriteCSV(out)
 	if err != nil {
-		log.Fatalf("Failure: %v", err)
+		log.Fatalf("Failed: %v", err)
+	}
+	shortest, bottlenecks := curve.Bottlenecks()
+	if len(bottlenecks) > 10 {
+		log.Printf("Every bank can turn on up to %v batteries. Limited by %v banks, starting with %v", shortest, len(bottlenecks), bottlenecks[:10])
+	} else {
+		log.Printf("Every bank can turn on up to %v batteries. Limited by banks %v", shortest, bottlenecks)
 	}
-	return joltage, nil
 }
 
-func makeNumber(highest []int) (int64, error) {
-	n := len(highest)
-	totalStr := ""
-	for i := 0; i < n; i++ {
-		totalStr += strconv.Itoa(highest[i])
-	}
-	total, err := strconv.ParseInt(totalStr, 10, 64)
-	if err != nil {
-		return 0, err
-	}
-	return total, nil
+// Selection is the set of batteries turned on in a bank.
+type Selection struct {
+	Digits  string
+	Joltage *big.Int
+	// Indices holds the position of every chosen battery in the bank, in order
+	Indices []int
 }
 
-func maybeSelect(highest []int, firstSelectable int, num int, sentinel int) (int, bool) {
-	n := len(highest)
-	for j := firstSelectable; j < n; j++ {
-		if highest[j] < num {
-			highest[j] = num
-			zeroed := setSentinel(highest, j+1, sentinel)
-			return zeroed, true
+/*
+handleBatteryBank picks the n batteries giving the largest joltage, or the smallest with opts.Smallest, while
+respecting the constraints in opts.
+
+Without gap or segment constraints, the batteries are kept in order on a stack. We can afford to skip len - n
+batteries in total, so while the next battery beats the top of the stack and we still have skips left, the top is
+popped: a better digit earlier always beats anything that could follow the worse one. Every battery is pushed and
+popped at most once, so this is O(len) regardless of n.
+
+With constraints, popping can break them, so selectConstrained is used instead.
+*/
+func handleBatteryBank(bank string, n int, opts SelectOptions) (Selection, error) {
+	if n < 1 {
+		return Selection{}, fmt.Errorf("Number of batteries needs to be >= 1. Given %v", n)
+	}
+	bankLen := len(bank)
+	if bankLen < n {
+		return Selection{}, fmt.Errorf("Battery bank lenght needs to be >= %v. Given %v", n, bankLen)
+	}
+	digits := make([]byte, bankLen)
+	for i := 0; i < bankLen; i++ {
+		num, err := getNum(bank, i)
+		if err != nil {
+			return Selection{}, err
+		}
+		digits[i] = byte('0' + num)
+	}
+	var selected []int
+	if opts.constrained() {
+		var err error
+		selected, err = selectConstrained(digits, n, opts)
+		if err != nil {
+			return Selection{}, err
+		}
+	} else {
+		skips := bankLen - n
+		selected = make([]int, 0, bankLen)
+		for i := 0; i < bankLen; i++ {
+			for skips > 0 && len(selected) > 0 && opts.better(digits[i], digits[selected[len(selected)-1]]) {
+				selected = selected[:len(selected)-1]
+				skips--
+			}
+			selected = append(selected, i)
 		}
+		selected = selected[:n]
 	}
-	return 0, false
+	chosen := make([]byte, n)
+	for i, index := range selected {
+		chosen[i] = digits[index]
+	}
+	joltage, err := makeNumber(string(chosen))
+	if err != nil {
+		return Selection{}, err
+	}
+	return Selection{Digits: string(chosen), Joltage: joltage, Indices: selected}, nil
 }
 
-func setSentinel(highest []int, start int, sentinel int) int {
-	n := len(highest)
-	for i := start; i < n; i++ {
-		highest[i] = sentinel
+func makeNumber(digits string) (*big.Int, error) {
+	total, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, fmt.Errorf("Invalid joltage %q", digits)
 	}
-	return n - start
+	return total, nil
 }
 
 func getNum(slice string, i int) (int, error) {
-	num, err := strconv.Atoi(slice[i : i+1])
-	if err != nil {
-		return 0, nil
+	c := slice[i]
+	if c < '0' || c > '9' {
+		r, _ := utf8.DecodeRuneInString(slice[i:])
+		return 0, fmt.Errorf("Invalid battery %q at column %v", r, utf8.RuneCountInString(slice[:i])+1)
 	}
-	return num, nil
+	return int(c - '0'), nil
 }
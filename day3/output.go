@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+const (
+	highlightStart = "\033[1;32m"
+	highlightEnd   = "\033[0m"
+)
+
+// BankReport is the JSON export of the batteries chosen in one bank.
+type BankReport struct {
+	Bank    int    `json:"bank"`
+	Joltage string `json:"joltage"`
+	Digits  string `json:"digits"`
+	Indices []int  `json:"indices"`
+}
+
+func newBankReport(bankNum int, selection Selection) BankReport {
+	return BankReport{
+		Bank:    bankNum,
+		Joltage: selection.Joltage.String(),
+		Digits:  selection.Digits,
+		Indices: selection.Indices,
+	}
+}
+
+func writeJSONReport(path string, reports []BankReport) error {
+	data, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// highlightBank returns the bank with the batteries at indices wrapped in
+// ANSI color codes, or in brackets if color is false. indices must be sorted.
+func highlightBank(bank string, indices []int, color bool) string {
+	start, end := "[", "]"
+	if color {
+		start, end = highlightStart, highlightEnd
+	}
+	var sb strings.Builder
+	next := 0
+	for i := 0; i < len(bank); i++ {
+		if next < len(indices) && indices[next] == i {
+			sb.WriteString(start)
+			sb.WriteByte(bank[i])
+			sb.WriteString(end)
+			next++
+		} else {
+			sb.WriteByte(bank[i])
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func Test_highlightBank(t *testing.T) {
+	data := []struct {
+		name     string
+		bank     string
+		indices  []int
+		color    bool
+		expected string
+	}{
+		{"none", "12345", []int{}, false, "12345"},
+		{"ends", "12345", []int{0, 4}, false, "[1]234[5]"},
+		{"adjacent", "12345", []int{1, 2}, false, "1[2][3]45"},
+		{"all", "987", []int{0, 1, 2}, false, "[9][8][7]"},
+		{"color", "818", []int{1}, true, "8" + highlightStart + "1" + highlightEnd + "8"},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			if got := highlightBank(d.bank, d.indices, d.color); got != d.expected {
+				t.Errorf("Expected %q, got %q", d.expected, got)
+			}
+		})
+	}
+}
+
+func Test_writeJSONReport(t *testing.T) {
+	banks := []string{"987654321111111", "818181911112111"}
+	reports := make([]BankReport, len(banks))
+	for k, bank := range banks {
+		selection, err := handleBatteryBank(bank, 2, SelectOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		reports[k] = newBankReport(k+1, selection)
+	}
+	path := filepath.Join(t.TempDir(), "report.json")
+	err := writeJSONReport(path, reports)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded []BankReport
+	err = json.Unmarshal(data, &loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []BankReport{
+		{Bank: 1, Joltage: "98", Digits: "98", Indices: []int{0, 1}},
+		{Bank: 2, Joltage: "92", Digits: "92", Indices: []int{6, 11}},
+	}
+	if len(loaded) != len(expected) {
+		t.Fatalf("Expected %v reports, got %v", len(expected), len(loaded))
+	}
+	for k := range expected {
+		if loaded[k].Bank != expected[k].Bank || loaded[k].Joltage != expected[k].Joltage ||
+			loaded[k].Digits != expected[k].Digits || !slices.Equal(loaded[k].Indices, expected[k].Indices) {
+			t.Errorf("Expected %+v, got %+v", expected[k], loaded[k])
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+)
+
+// SelectOptions constrains which batteries of a bank can be turned on
+// together.
+type SelectOptions struct {
+	// Smallest picks the smallest joltage instead of the largest
+	Smallest bool
+	// MinGap is the minimum number of unused batteries between two chosen ones
+	MinGap int
+	// SegmentLen splits the bank into contiguous segments of this length. 0
+	// means the whole bank is one segment
+	SegmentLen int
+	// SegmentCap is the most batteries that can be chosen from one segment. 0
+	// means no cap
+	SegmentCap int
+}
+
+func (o SelectOptions) constrained() bool {
+	return o.MinGap > 0 || o.SegmentCap > 0
+}
+
+// better reports whether digit a is preferred over digit b.
+func (o SelectOptions) better(a, b byte) bool {
+	if o.Smallest {
+		return a < b
+	}
+	return a > b
+}
+
+func (o SelectOptions) segment(i int) int {
+	if o.SegmentLen <= 0 {
+		return 0
+	}
+	return i / o.SegmentLen
+}
+
+/*
+selectConstrained returns the indices of the n batteries giving the best joltage under the gap and segment
+constraints.
+
+All candidates have n digits, so the best joltage is the lexicographically best digit string, and we can fix the
+digits one at a time: take the best digit that still leaves room to choose the rest, at its earliest position.
+The earliest position never hurts, since everything choosable after a later copy of the digit is also choosable
+after the earlier one.
+
+"Leaves room" is answered by a DP. most[i][c] is the largest number of batteries that can be chosen from
+positions i onward, when c batteries were already chosen in the segment of position i:
+most[i][c] = max(most[i + 1][c1], 1 + most[i + gap + 1][c2])  (taking i only if c < cap)
+where c1 and c2 carry the count over only while staying in the same segment. If k batteries fit, so do fewer,
+so the DP is enough to check feasibility. Building it is O(len * cap), and picking the digits O(n * len).
+*/
+func selectConstrained(digits []byte, n int, opts SelectOptions) ([]int, error) {
+	if opts.MinGap < 0 || opts.SegmentLen < 0 || opts.SegmentCap < 0 {
+		return nil, fmt.Errorf("Selection constraints must be >= 0. Given %+v", opts)
+	}
+	bankLen := len(digits)
+	segmentCap := opts.SegmentCap
+	if segmentCap == 0 || segmentCap > bankLen {
+		segmentCap = bankLen
+	}
+	// carry returns the count in the segment of to, given count in the segment of from
+	carry := func(from, to, count int) int {
+		if to < bankLen && opts.segment(from) == opts.segment(to) {
+			return count
+		}
+		return 0
+	}
+	most := make([][]int, bankLen+opts.MinGap+2)
+	for i := range most {
+		most[i] = make([]int, segmentCap+1)
+	}
+	for i := bankLen - 1; i >= 0; i-- {
+		for c := 0; c <= segmentCap; c++ {
+			best := most[i+1][carry(i, i+1, c)]
+			if c < segmentCap {
+				next := i + opts.MinGap + 1
+				best = max(best, 1+most[next][carry(i, next, c+1)])
+			}
+			most[i][c] = best
+		}
+	}
+	if most[0][0] < n {
+		return nil, fmt.Errorf("Cannot choose %v batteries from a bank of length %v with constraints %+v", n, bankLen, opts)
+	}
+
+	selected := make([]int, 0, n)
+	start, count := 0, 0
+	for k := n; k > 0; k-- {
+		chosen := -1
+		for j := start; j < bankLen; j++ {
+			c := carry(start, j, count)
+			if c >= segmentCap {
+				continue
+			}
+			next := j + opts.MinGap + 1
+			if 1+most[next][carry(j, next, c+1)] < k {
+				continue
+			}
+			if chosen == -1 || opts.better(digits[j], digits[chosen]) {
+				chosen = j
+			}
+		}
+		selected = append(selected, chosen)
+		c := count
+		if opts.segment(start) != opts.segment(chosen) {
+			c = 0
+		}
+		start = chosen + opts.MinGap + 1
+		count = carry(chosen, start, c+1)
+	}
+	return selected, nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func Test_handleBatteryBank_example(t *testing.T) {
+	data := []struct {
+		name     string
+		bank     string
+		n        int
+		expected string
+	}{
+		{"bank_1_n2", "987654321111111", 2, "98"},
+		{"bank_2_n2", "811111111111119", 2, "89"},
+		{"bank_3_n2", "234234234234278", 2, "78"},
+		{"bank_4_n2", "818181911112111", 2, "92"},
+		{"bank_1_n12", "987654321111111", 12, "987654321111"},
+		{"bank_2_n12", "811111111111119", 12, "811111111119"},
+		{"bank_3_n12", "234234234234278", 12, "434234234278"},
+		{"bank_4_n12", "818181911112111", 12, "888911112111"},
+		{"whole_bank", "4321", 4, "4321"},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			selection, err := handleBatteryBank(d.bank, d.n, SelectOptions{})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if selection.Digits != d.expected || selection.Joltage.String() != d.expected {
+				t.Errorf("Expected %v, got %v and %v", d.expected, selection.Digits, selection.Joltage)
+			}
+		})
+	}
+}
+
+// exhaustiveSelect tries every set of n batteries and returns the best
+// joltage satisfying opts, or "" if none does.
+func exhaustiveSelect(bank string, n int, opts SelectOptions) string {
+	best := ""
+	for mask := 0; mask < 1<<len(bank); mask++ {
+		indices := make([]int, 0, n)
+		for i := range bank {
+			if mask&(1<<i) != 0 {
+				indices = append(indices, i)
+			}
+		}
+		if len(indices) != n || !satisfies(indices, opts) {
+			continue
+		}
+		digits := make([]byte, n)
+		for i, index := range indices {
+			digits[i] = bank[index]
+		}
+		candidate := string(digits)
+		if best == "" || (opts.Smallest && candidate < best) || (!opts.Smallest && candidate > best) {
+			best = candidate
+		}
+	}
+	return best
+}
+
+func satisfies(indices []int, opts SelectOptions) bool {
+	perSegment := make(map[int]int)
+	for i, index := range indices {
+		if i > 0 && index-indices[i-1]-1 < opts.MinGap {
+			return false
+		}
+		perSegment[opts.segment(index)]++
+		if opts.SegmentCap > 0 && perSegment[opts.segment(index)] > opts.SegmentCap {
+			return false
+		}
+	}
+	return true
+}
+
+func Test_handleBatteryBank_exhaustive(t *testing.T) {
+	data := []struct {
+		name string
+		opts SelectOptions
+	}{
+		{"largest", SelectOptions{}},
+		{"smallest", SelectOptions{Smallest: true}},
+		{"gap_1", SelectOptions{MinGap: 1}},
+		{"gap_2_smallest", SelectOptions{MinGap: 2, Smallest: true}},
+		{"segment_cap", SelectOptions{SegmentLen: 3, SegmentCap: 1}},
+		{"segment_cap_2_smallest", SelectOptions{SegmentLen: 4, SegmentCap: 2, Smallest: true}},
+		{"gap_and_segment_cap", SelectOptions{MinGap: 1, SegmentLen: 3, SegmentCap: 1}},
+		{"whole_bank_cap", SelectOptions{SegmentCap: 3}},
+	}
+	rng := rand.New(rand.NewSource(25))
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			for trial := 0; trial < 300; trial++ {
+				bankLen := 1 + rng.Intn(10)
+				digits := make([]byte, bankLen)
+				for i := range digits {
+					digits[i] = byte('0' + rng.Intn(4))
+				}
+				bank := string(digits)
+				n := 1 + rng.Intn(bankLen)
+				expected := exhaustiveSelect(bank, n, d.opts)
+				selection, err := handleBatteryBank(bank, n, d.opts)
+				if expected == "" {
+					if err == nil {
+						t.Errorf("Bank %v, n %v: expected an error, got %v", bank, n, selection.Digits)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("Bank %v, n %v: unexpected error: %v", bank, n, err)
+				}
+				if selection.Digits != expected {
+					t.Errorf("Bank %v, n %v: expected %v, got %v", bank, n, expected, selection.Digits)
+				}
+				if !satisfies(selection.Indices, d.opts) {
+					t.Errorf("Bank %v, n %v: indices %v break the constraints", bank, n, selection.Indices)
+				}
+			}
+		})
+	}
+}
+
+func Test_bankCurve(t *testing.T) {
+	rng := rand.New(rand.NewSource(37))
+	for _, opts := range []SelectOptions{{}, {Smallest: true}, {MinGap: 1}} {
+		for trial := 0; trial < 200; trial++ {
+			digits := make([]byte, 1+rng.Intn(30))
+			for i := range digits {
+				digits[i] = byte('0' + rng.Intn(10))
+			}
+			bank := string(digits)
+			joltages, err := bankCurve(bank, opts)
+			if err != nil {
+				t.Fatalf("Bank %v: unexpected error: %v", bank, err)
+			}
+			for n := 1; n <= len(joltages); n++ {
+				selection, selectErr := handleBatteryBank(bank, n, opts)
+				if selectErr != nil {
+					t.Fatalf("Bank %v, n %v: unexpected error: %v", bank, n, selectErr)
+				}
+				if joltages[n-1].Cmp(selection.Joltage) != 0 {
+					t.Errorf("Bank %v, n %v, %+v: expected %v, got %v", bank, n, opts, selection.Joltage, joltages[n-1])
+				}
+			}
+		}
+	}
+}
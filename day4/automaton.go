@@ -0,0 +1,275 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// LifeRule is a Life-like rule in B/S notation, with optional Generations
+// style decay states: "B3/S23" is Conway's Life, "B2/S/C3" is Brian's Brain.
+// State 0 is dead and state 1 alive. A live cell that doesn't survive decays
+// through states 2 to States - 1 before dying. Only live cells count as
+// neighbors.
+//
+// Counts are single digits as usual ("B36"), or, for neighborhoods with more
+// than 9 cells, a comma separated list of counts and count ranges
+// ("B3,10-12/S2,3"). A lone count above 9 needs a trailing comma ("B10,").
+type LifeRule struct {
+	Birth   map[int]bool
+	Survive map[int]bool
+	States  int
+}
+
+// maxStates is how many states fit in the uint8 cells of a grid.
+const maxStates = 256
+
+// maxNeighborCount bounds the counts a rule may list, so a range like
+// 0-999999999 can't fill a huge map.
+const maxNeighborCount = 1 << 16
+
+// LifePresets are rules known by name. paper-rolls is the puzzle: rolls with
+// fewer than 4 neighboring rolls are removed, and nothing is ever added.
+var LifePresets = map[string]string{
+	"paper-rolls":  "B/S45678",
+	"life":         "B3/S23",
+	"highlife":     "B36/S23",
+	"seeds":        "B2/S",
+	"day-night":    "B3678/S34678",
+	"brians-brain": "B2/S/C3",
+}
+
+// ParseLifeRule parses B/S notation or the name of a preset.
+func ParseLifeRule(s string) (LifeRule, error) {
+	if preset, ok := LifePresets[s]; ok {
+		s = preset
+	}
+	rule := LifeRule{States: 2}
+	parts := strings.Split(strings.ToUpper(s), "/")
+	if len(parts) < 2 || len(parts) > 3 || !strings.HasPrefix(parts[0], "B") || !strings.HasPrefix(parts[1], "S") {
+		return LifeRule{}, fmt.Errorf("Rule must look like B3/S23 or B2/S/C3. Got %q", s)
+	}
+	var err error
+	rule.Birth, err = parseCounts(parts[0][1:])
+	if err != nil {
+		return LifeRule{}, fmt.Errorf("Rule %q: %v", s, err)
+	}
+	rule.Survive, err = parseCounts(parts[1][1:])
+	if err != nil {
+		return LifeRule{}, fmt.Errorf("Rule %q: %v", s, err)
+	}
+	if len(parts) == 3 {
+		if !strings.HasPrefix(parts[2], "C") {
+			return LifeRule{}, fmt.Errorf("Number of states must look like C3. Got %q", parts[2])
+		}
+		states, err := strconv.Atoi(parts[2][1:])
+		if err != nil {
+			return LifeRule{}, err
+		}
+		if states < 2 || states > maxStates {
+			return LifeRule{}, fmt.Errorf("Number of states must be between 2 and %v. Got %v", maxStates, states)
+		}
+		rule.States = states
+	}
+	return rule, nil
+}
+
+// parseCounts parses the neighbor counts after B or S: single digits, or a
+// comma separated list of counts and ranges like 10-12.
+func parseCounts(s string) (map[int]bool, error) {
+	counts := make(map[int]bool)
+	if !strings.ContainsAny(s, ",-") {
+		for _, c := range s {
+			if c < '0' || c > '9' {
+				return nil, fmt.Errorf("Invalid neighbor count %q", c)
+			}
+			counts[int(c-'0')] = true
+		}
+		return counts, nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		if item == "" {
+			continue
+		}
+		bounds := strings.Split(item, "-")
+		if len(bounds) > 2 {
+			return nil, fmt.Errorf("Invalid neighbor count range %q", item)
+		}
+		lower, err := strconv.Atoi(bounds[0])
+		if err != nil || lower < 0 {
+			return nil, fmt.Errorf("Invalid neighbor count %q", bounds[0])
+		}
+		upper := lower
+		if len(bounds) == 2 {
+			upper, err = strconv.Atoi(bounds[1])
+			if err != nil || upper < lower {
+				return nil, fmt.Errorf("Invalid neighbor count range %q", item)
+			}
+		}
+		if upper > maxNeighborCount {
+			return nil, fmt.Errorf("Neighbor count must be <= %v. Got %v", maxNeighborCount, upper)
+		}
+		for count := lower; count <= upper; count++ {
+			counts[count] = true
+		}
+	}
+	return counts, nil
+}
+
+// Next returns the state of a cell in the next generation, given its state
+// and how many live neighbors it has.
+func (r LifeRule) Next(state uint8, live int) uint8 {
+	switch {
+	case state == 0 && r.Birth[live]:
+		return 1
+	case state == 0:
+		return 0
+	case state == 1 && r.Survive[live]:
+		return 1
+	case int(state)+1 < r.States:
+		return state + 1
+	}
+	return 0
+}
+
+// Automaton runs a LifeRule over a grid of states.
+type Automaton struct {
+	Rule         LifeRule
+	Neighborhood Neighborhood
+	// Symbols[s] is the character for state s
+	Symbols []rune
+}
+
+func NewAutomaton(rule LifeRule, neighborhood Neighborhood, symbols string) (*Automaton, error) {
+	runes := []rune(symbols)
+	if len(runes) != rule.States {
+		return nil, fmt.Errorf("Rule has %v states but %v symbols were given (%q)", rule.States, len(runes), symbols)
+	}
+	return &Automaton{Rule: rule, Neighborhood: neighborhood, Symbols: runes}, nil
+}
+
+// Encode turns a grid of symbols into states.
+func (a *Automaton) Encode(grid [][]rune) ([][]uint8, error) {
+	states := make([][]uint8, len(grid))
+	for j, row := range grid {
+		states[j] = make([]uint8, len(row))
+		for i, c := range row {
+			s := -1
+			for k, symbol := range a.Symbols {
+				if symbol == c {
+					s = k
+				}
+			}
+			if s == -1 {
+				return nil, fmt.Errorf("Unknown symbol %q at row %v, column %v", c, j+1, i+1)
+			}
+			states[j][i] = uint8(s)
+		}
+	}
+	return states, nil
+}
+
+// Decode turns a grid of states into symbols.
+func (a *Automaton) Decode(states [][]uint8) [][]rune {
+	grid := make([][]rune, len(states))
+	for j, row := range states {
+		grid[j] = make([]rune, len(row))
+		for i, s := range row {
+			grid[j][i] = a.Symbols[s]
+		}
+	}
+	return grid
+}
+
+// Step returns the next generation.
+func (a *Automaton) Step(states [][]uint8) [][]uint8 {
+	yLen, xLen := len(states), len(states[0])
+	next := make([][]uint8, yLen)
+	for j := 0; j < yLen; j++ {
+		next[j] = make([]uint8, xLen)
+		for i := 0; i < xLen; i++ {
+			live := 0
+			for _, o := range a.Neighborhood.Offsets {
+				jj, ii, ok := a.Neighborhood.Neighbor(yLen, xLen, j, i, o)
+				if ok && states[jj][ii] == 1 {
+					live++
+				}
+			}
+			next[j][i] = a.Rule.Next(states[j][i], live)
+		}
+	}
+	return next
+}
+
+// AutomatonRun is the outcome of running an automaton.
+type AutomatonRun struct {
+	Final [][]uint8
+	// Generations is how many steps were taken
+	Generations int
+	// CycleStart is the first generation of the cycle the run ended in, and
+	// CycleLength its period (1 for a fixpoint). CycleLength is 0 if the run
+	// stopped at the generation limit first.
+	CycleStart  int
+	CycleLength int
+}
+
+func (r AutomatonRun) Fixpoint() bool {
+	return r.CycleLength == 1
+}
+
+/*
+Run steps the automaton until the grid repeats or maxGenerations is reached.
+
+Every generation is hashed. A hash seen before means a likely cycle; since hashes can collide, it is confirmed by
+replaying from the start to the earlier generation and comparing full grids, which only happens on a hit.
+*/
+func (a *Automaton) Run(initial [][]uint8, maxGenerations int) AutomatonRun {
+	seen := map[uint64]int{hashStates(initial): 0}
+	states := initial
+	for gen := 1; gen <= maxGenerations; gen++ {
+		states = a.Step(states)
+		h := hashStates(states)
+		if earlier, ok := seen[h]; ok {
+			replayed := initial
+			for k := 0; k < earlier; k++ {
+				replayed = a.Step(replayed)
+			}
+			if statesEqual(replayed, states) {
+				return AutomatonRun{Final: states, Generations: gen, CycleStart: earlier, CycleLength: gen - earlier}
+			}
+		}
+		seen[h] = gen
+	}
+	return AutomatonRun{Final: states, Generations: maxGenerations}
+}
+
+func hashStates(states [][]uint8) uint64 {
+	h := fnv.New64a()
+	for _, row := range states {
+		h.Write(row)
+	}
+	return h.Sum64()
+}
+
+func statesEqual(a, b [][]uint8) bool {
+	for j := range a {
+		if string(a[j]) != string(b[j]) {
+			return false
+		}
+	}
+	return true
+}
+
+// CountState returns how many cells are in state s.
+func CountState(states [][]uint8, s uint8) int {
+	count := 0
+	for _, row := range states {
+		for _, c := range row {
+			if c == s {
+				count++
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math/bits"
+	"sync"
+)
+
+// BitGrid is a grid with one bit per cell, set for the cells holding a roll.
+// Every row starts on a new word, with column i in bit i % 64 of word i / 64.
+// Bits past the end of a row are always 0.
+type BitGrid struct {
+	yLen  int
+	xLen  int
+	words int
+	bits  []uint64
+}
+
+func NewBitGrid(yLen, xLen int) *BitGrid {
+	words := (xLen + 63) / 64
+	return &BitGrid{yLen: yLen, xLen: xLen, words: words, bits: make([]uint64, yLen*words)}
+}
+
+// ReadBitGrid reads a grid straight into bits, setting the cells holding
+// symbol, without ever keeping the whole text grid in memory.
+func ReadBitGrid(scanner *bufio.Scanner, symbol rune) (*BitGrid, error) {
+	g := &BitGrid{}
+	for scanner.Scan() {
+		row := []rune(scanner.Text())
+		if g.yLen == 0 {
+			g.xLen = len(row)
+			g.words = (g.xLen + 63) / 64
+		}
+		if len(row) != g.xLen {
+			return nil, fmt.Errorf("Row %v has length %v. Expected %v", g.yLen+1, len(row), g.xLen)
+		}
+		words := make([]uint64, g.words)
+		for i, c := range row {
+			if c == symbol {
+				words[i/64] |= 1 << (i % 64)
+			}
+		}
+		g.bits = append(g.bits, words...)
+		g.yLen++
+	}
+	if g.yLen == 0 {
+		return nil, fmt.Errorf("Grid is empty")
+	}
+	return g, nil
+}
+
+func (g *BitGrid) Row(j int) []uint64 {
+	return g.bits[j*g.words : (j+1)*g.words]
+}
+
+func (g *BitGrid) Get(j, i int) bool {
+	return g.Row(j)[i/64]&(1<<(i%64)) != 0
+}
+
+func (g *BitGrid) Set(j, i int) {
+	g.Row(j)[i/64] |= 1 << (i % 64)
+}
+
+func (g *BitGrid) Count() int {
+	count := 0
+	for _, w := range g.bits {
+		count += bits.OnesCount64(w)
+	}
+	return count
+}
+
+// wordAt returns the 64 bits of row starting at column bit, which may be
+// negative or past the end. Columns outside the row read as 0.
+func wordAt(row []uint64, bit int) uint64 {
+	q, r := bit>>6, uint(bit&63)
+	var lo, hi uint64
+	if q >= 0 && q < len(row) {
+		lo = row[q]
+	}
+	if q+1 >= 0 && q+1 < len(row) {
+		hi = row[q+1]
+	}
+	if r == 0 {
+		return lo
+	}
+	return lo>>r | hi<<(64-r)
+}
+
+// bitStepper holds the scratch space of one worker.
+type bitStepper struct {
+	rule Rule
+	// planes[p][w] is bit p of the neighbor count of the 64 cells in word w
+	planes  [][]uint64
+	shifted []uint64
+}
+
+func newBitStepper(rule Rule, words int) *bitStepper {
+	planes := make([][]uint64, bits.Len(uint(len(rule.Offsets))))
+	for p := range planes {
+		planes[p] = make([]uint64, words)
+	}
+	return &bitStepper{rule: rule, planes: planes, shifted: make([]uint64, words)}
+}
+
+/*
+step writes rows [lo, hi) of the next wave into next and returns how many rolls were removed from them.
+
+The neighbor counts of a whole word of cells are computed at once. Every offset shifts a neighboring row so that
+each cell lines up with its neighbor, and the shifted words are added into the counts with a ripple-carry adder
+working on bit planes: plane p holds bit p of the 64 counts. The counts are then compared to the threshold from the
+most significant plane down, keeping track of which cells are still equal to it and which are already below.
+*/
+func (s *bitStepper) step(cur, next *BitGrid, lo, hi int) int {
+	removed := 0
+	for j := lo; j < hi; j++ {
+		for _, plane := range s.planes {
+			clear(plane)
+		}
+		for _, o := range s.rule.Offsets {
+			jj, _, ok := s.rule.Neighbor(cur.yLen, 1, j, 0, Offset{DJ: o.DJ})
+			if !ok {
+				continue
+			}
+			s.shiftRow(cur.Row(jj), o.DI, cur.xLen)
+			for w, x := range s.shifted {
+				for p := 0; x != 0 && p < len(s.planes); p++ {
+					s.planes[p][w], x = s.planes[p][w]^x, s.planes[p][w]&x
+				}
+			}
+		}
+		alive, nextRow := cur.Row(j), next.Row(j)
+		for w := range alive {
+			remove := alive[w] & s.removable(w)
+			nextRow[w] = alive[w] &^ remove
+			removed += bits.OnesCount64(remove)
+		}
+	}
+	return removed
+}
+
+// shiftRow lines up row with the neighbors di columns away.
+func (s *bitStepper) shiftRow(row []uint64, di, xLen int) {
+	if s.rule.Edges == Toroidal {
+		di = ((di % xLen) + xLen) % xLen
+		for w := range s.shifted {
+			s.shifted[w] = wordAt(row, w*64+di) | wordAt(row, w*64+di-xLen)
+		}
+		return
+	}
+	for w := range s.shifted {
+		s.shifted[w] = wordAt(row, w*64+di)
+	}
+}
+
+// removable returns the cells of word w whose count compares to the
+// threshold as the rule requires.
+func (s *bitStepper) removable(w int) uint64 {
+	var less, equal uint64
+	switch {
+	case s.rule.Threshold < 0:
+	case s.rule.Threshold >= 1<<len(s.planes):
+		less = ^uint64(0)
+	default:
+		equal = ^uint64(0)
+		for p := len(s.planes) - 1; p >= 0; p-- {
+			c := s.planes[p][w]
+			if s.rule.Threshold&(1<<p) != 0 {
+				less |= equal &^ c
+				equal &= c
+			} else {
+				equal &^= c
+			}
+		}
+	}
+	switch s.rule.Compare {
+	case LessThan:
+		return less
+	case LessOrEqual:
+		return less | equal
+	case Equal:
+		return equal
+	case GreaterOrEqual:
+		return ^less
+	case GreaterThan:
+		return ^(less | equal)
+	}
+	return 0
+}
+
+/*
+RemoveRollsBits forklifts rolls from a bit grid until none can be removed, or for at most maxWaves iterations when
+maxWaves > 0. The grid is left holding the rolls that remain, and the returned removal has no Waves.
+
+The rows are split into one horizontal stripe per worker. Every wave, each worker reads the current grid and writes
+its stripe of the next one, and the workers wait for each other before the grids are swapped.
+*/
+func RemoveRollsBits(grid *BitGrid, rule Rule, workers int, maxWaves int) Removal {
+	workers = max(min(workers, grid.yLen), 1)
+	steppers := make([]*bitStepper, workers)
+	for w := range steppers {
+		steppers[w] = newBitStepper(rule, grid.words)
+	}
+	removed := make([]int, workers)
+	cur, next := grid, NewBitGrid(grid.yLen, grid.xLen)
+	removal := Removal{WaveCounts: make([]int, 0), Rolls: grid.Count()}
+	for {
+		var wg sync.WaitGroup
+		for w, s := range steppers {
+			lo, hi := w*grid.yLen/workers, (w+1)*grid.yLen/workers
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				removed[w] = s.step(cur, next, lo, hi)
+			}()
+		}
+		wg.Wait()
+		total := 0
+		for _, count := range removed {
+			total += count
+		}
+		if total == 0 {
+			break
+		}
+		if maxWaves > 0 && len(removal.WaveCounts) == maxWaves {
+			removal.Stopped = true
+			break
+		}
+		removal.WaveCounts = append(removal.WaveCounts, total)
+		cur, next = next, cur
+	}
+	if cur != grid {
+		copy(grid.bits, cur.bits)
+	}
+	return removal
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// GIFColors are the colors of the cells in an animation frame.
+type GIFColors struct {
+	Empty        color.RGBA
+	Paper        color.RGBA
+	RemovedNow   color.RGBA
+	RemovedEarly color.RGBA
+}
+
+func DefaultGIFColors() GIFColors {
+	return GIFColors{
+		Empty:        heatmapEmpty,
+		Paper:        heatmapRemaining,
+		RemovedNow:   color.RGBA{R: 220, G: 30, B: 30, A: 255},
+		RemovedEarly: color.RGBA{R: 200, G: 200, B: 200, A: 255},
+	}
+}
+
+// ParseColor parses a color written as rrggbb, with or without a leading #.
+func ParseColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("Color must look like #rrggbb. Got %q", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("Color must look like #rrggbb. Got %q", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
+}
+
+// Palette indices of the cell kinds
+const (
+	gifEmpty = iota
+	gifPaper
+	gifRemovedNow
+	gifRemovedEarly
+)
+
+/*
+RemovalGIF builds an animation of the removal: the first frame is the grid before anything is removed, and frame k
+shows the rolls removed in wave k alongside the ones removed before it. delay is the time between frames in 100ths
+of a second, and the last frame is held for 2 seconds.
+
+grid is the grid after the removal, so removed rolls are found through removal.Waves rather than by their symbol.
+*/
+func RemovalGIF(grid [][]rune, removal Removal, symbol rune, cellSize int, colors GIFColors, delay int) (*gif.GIF, error) {
+	if cellSize < 1 {
+		return nil, fmt.Errorf("Cell size must be >= 1. Got %v", cellSize)
+	}
+	yLen, xLen := GetGridDimensions(grid)
+	palette := color.Palette{colors.Empty, colors.Paper, colors.RemovedNow, colors.RemovedEarly}
+	anim := &gif.GIF{}
+	for frame := 0; frame <= removal.Iterations(); frame++ {
+		img := image.NewPaletted(image.Rect(0, 0, xLen*cellSize, yLen*cellSize), palette)
+		for j := 0; j < yLen; j++ {
+			for i := 0; i < xLen; i++ {
+				var kind uint8 = gifEmpty
+				wave := removal.Waves[j][i]
+				switch {
+				case wave == 0 && grid[j][i] == symbol, wave > frame:
+					kind = gifPaper
+				case wave == 0:
+				case wave == frame:
+					kind = gifRemovedNow
+				default:
+					kind = gifRemovedEarly
+				}
+				if kind == gifEmpty {
+					continue
+				}
+				for y := j * cellSize; y < (j+1)*cellSize; y++ {
+					row := img.Pix[y*img.Stride:]
+					for x := i * cellSize; x < (i+1)*cellSize; x++ {
+						row[x] = kind
+					}
+				}
+			}
+		}
+		anim.Image = append(anim.Image, img)
+		anim.Delay = append(anim.Delay, delay)
+	}
+	anim.Delay[len(anim.Delay)-1] = max(delay, 200)
+	return anim, nil
+}
+
+func WriteGIF(path string, anim *gif.GIF) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = gif.EncodeAll(f, anim)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -2,72 +2,166 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"image/color"
+	"log"
 	"os"
+	"runtime"
 )
 
 func main() {
+	showWaves := flag.Bool("waves", false, "print the iteration each roll was removed in instead of the final grid")
+	showWaveCounts := flag.Bool("wave-counts", false, "print how many rolls were removed in every iteration")
+	pngPath := flag.String("png", "", "write a heatmap of the iteration each roll was removed in to this PNG file")
+	cellSize := flag.Int("cell-size", 4, "size in pixels of a cell in the heatmap and the animation")
+	gifPath := flag.String("gif", "", "write an animation of the removal, one frame per iteration, to this GIF file")
+	gifDelay := flag.Int("gif-delay", 20, "time between animation frames in 100ths of a second")
+	gifColors := DefaultGIFColors()
+	flag.Func("color-paper", "animation color of paper rolls as #rrggbb (default #202020)", colorFlag(&gifColors.Paper))
+	flag.Func("color-now", "animation color of rolls removed in the current iteration as #rrggbb (default #dc1e1e)", colorFlag(&gifColors.RemovedNow))
+	flag.Func("color-earlier", "animation color of rolls removed in earlier iterations as #rrggbb (default #c8c8c8)", colorFlag(&gifColors.RemovedEarly))
+	flag.Func("color-empty", "animation color of empty cells as #rrggbb (default #ffffff)", colorFlag(&gifColors.Empty))
+	neighborhood := flag.String("neighborhood", "moore", "neighborhood shape: moore or vonneumann")
+	radius := flag.Int("radius", 1, "neighborhood radius")
+	offsets := flag.String("offsets", "", "custom neighborhood as dj,di;dj,di;... (overrides -neighborhood)")
+	wrap := flag.Bool("wrap", false, "wrap neighbors around the grid edges")
+	symbol := flag.String("symbol", "@", "symbol of the cells that get removed and counted")
+	compare := flag.String("compare", "<", "how the neighbor count compares to the threshold for removal: <, <=, ==, >=, >")
+	threshold := flag.Int("threshold", 4, "neighbor count threshold for removal")
+	automaton := flag.String("automaton", "", "run a Life-like automaton instead: a rule like B3/S23 or B2/S/C3, or a preset (paper-rolls, life, highlife, seeds, day-night, brians-brain)")
+	generations := flag.Int("generations", 1000, "maximum number of automaton generations")
+	symbols := flag.String("symbols", "", "automaton symbol of every state, dead first (default .@ for 2 states, .@x for 3)")
+	maxIterations := flag.Int("max-iterations", 0, "stop removing rolls after this many iterations (0 for no limit)")
+	bitGrid := flag.Bool("bits", false, "store the grid as one bit per cell and only print the counts, for very large grids")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of horizontal stripes updated in parallel with -bits")
+	flag.Parse()
+	n, err := newNeighborhood(*neighborhood, *radius, *offsets, *wrap)
+	if err != nil {
+		log.Fatalf("Failure: %v", err)
+	}
+	rule, err := newRule(n, *symbol, *compare, *threshold)
+	if err != nil {
+		log.Fatalf("Failure: %v", err)
+	}
 	scanner := bufio.NewScanner(os.Stdin)
+	if *bitGrid {
+		if *showWaves || *pngPath != "" || *gifPath != "" || *automaton != "" {
+			log.Fatalf("Failure: -bits does not support -waves, -png, -gif or -automaton")
+		}
+		main_bits(scanner, rule, *workers, *maxIterations, *showWaveCounts)
+		return
+	}
 	grid := ReadGrid(scanner)
-	gridCopy := CopyGrid(grid)
+	if *automaton != "" {
+		main_automaton(grid, n, *automaton, *symbols, *generations)
+		return
+	}
 	PrintGrid(grid)
-	yLen, xLen := GetGridDimensions(grid)
-	symbol := '@'
-	count := 0
-	maxNumIterations := 10000000
-	gridsNotEqual := true
-	iterations := 0
-	for gridsNotEqual && iterations < maxNumIterations {
-		for j := 0; j < yLen; j++ {
-			for i := 0; i < xLen; i++ {
-				if grid[j][i] == symbol {
-					neighbors := CountNeighbors(grid, j, i, symbol)
-					canForkLift := CanForkliftPaperRole(neighbors)
-					if canForkLift {
-						count++
-						gridCopy[j][i] = 'x'
-					}
-				}
-			}
+	removal := RemoveRolls(grid, rule, 'x', *maxIterations)
+	if *showWaves {
+		PrintGrid(WaveGrid(grid, removal))
+	} else {
+		PrintGrid(grid)
+	}
+	if *showWaveCounts {
+		for k, count := range removal.WaveCounts {
+			fmt.Printf("Iteration %3v: %v\n", k+1, count)
+		}
+	}
+	if *pngPath != "" {
+		err := WriteHeatmap(*pngPath, grid, removal, rule.Symbol, *cellSize)
+		if err != nil {
+			log.Fatalf("Failure: %v", err)
+		}
+	}
+	if *gifPath != "" {
+		anim, err := RemovalGIF(grid, removal, rule.Symbol, *cellSize, gifColors, *gifDelay)
+		if err != nil {
+			log.Fatalf("Failure: %v", err)
 		}
-		PrintGrid(gridCopy)
-		gridsNotEqual = !GridsEqual(grid, gridCopy)
-		if gridsNotEqual {
-			grid = gridCopy
-			gridCopy = CopyGrid(grid)
+		err = WriteGIF(*gifPath, anim)
+		if err != nil {
+			log.Fatalf("Failure: %v", err)
 		}
-		iterations++
 	}
-	fmt.Printf("Forkliftable paper rolls: %v", count)
+	printRemoval(removal)
 }
 
-func GridsEqual(grid1 [][]rune, grid2 [][]rune) bool {
-	yLen1, xLen1 := GetGridDimensions(grid1)
-	yLen2, xLen2 := GetGridDimensions(grid2)
-	if xLen1 != xLen2 || yLen1 != yLen2 {
-		return false
+// printRemoval reports the rolls removed in the first iteration (part 1) and
+// in all of them (part 2). When the iteration limit stopped the removal
+// early, part 2 is not known, so the rolls removed so far are reported.
+func printRemoval(removal Removal) {
+	fmt.Printf("Iterations: %v\n", removal.Iterations())
+	fmt.Printf("Part 1 result: %v\n", removal.First())
+	if removal.Stopped {
+		fmt.Printf("Stopped after %v iterations. Removed so far: %v\n", removal.Iterations(), removal.Total())
+	} else {
+		fmt.Printf("Part 2 result: %v\n", removal.Total())
 	}
+	fmt.Printf("Paper rolls remaining: %v", removal.Remaining())
+}
 
-	for j := 0; j < yLen1; j++ {
-		for i := 0; i < xLen1; i++ {
-			if grid1[j][i] != grid2[j][i] {
-				return false
-			}
+func colorFlag(c *color.RGBA) func(string) error {
+	return func(s string) error {
+		parsed, err := ParseColor(s)
+		if err != nil {
+			return err
 		}
+		*c = parsed
+		return nil
 	}
-	return true
 }
 
-func CopyGrid(grid [][]rune) [][]rune {
-	yLen, xLen := GetGridDimensions(grid)
-	gridCopy := make([][]rune, yLen)
-	for j := 0; j < yLen; j++ {
-		gridCopy[j] = make([]rune, xLen)
-		for i := 0; i < xLen; i++ {
-			gridCopy[j][i] = grid[j][i]
+func main_bits(scanner *bufio.Scanner, rule Rule, workers int, maxIterations int, showWaveCounts bool) {
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1<<30)
+	grid, err := ReadBitGrid(scanner, rule.Symbol)
+	if err != nil {
+		log.Fatalf("Failure: %v", err)
+	}
+	removal := RemoveRollsBits(grid, rule, workers, maxIterations)
+	if showWaveCounts {
+		for k, count := range removal.WaveCounts {
+			fmt.Printf("Iteration %3v: %v\n", k+1, count)
+		}
+	}
+	printRemoval(removal)
+}
+
+func main_automaton(grid [][]rune, n Neighborhood, ruleStr string, symbols string, generations int) {
+	rule, err := ParseLifeRule(ruleStr)
+	if err != nil {
+		log.Fatalf("Failure: %v", err)
+	}
+	if symbols == "" {
+		switch rule.States {
+		case 2:
+			symbols = ".@"
+		case 3:
+			symbols = ".@x"
+		default:
+			log.Fatalf("Failure: rule has %v states, -symbols is required", rule.States)
 		}
 	}
-	return gridCopy
+	a, err := NewAutomaton(rule, n, symbols)
+	if err != nil {
+		log.Fatalf("Failure: %v", err)
+	}
+	states, err := a.Encode(grid)
+	if err != nil {
+		log.Fatalf("Failure: %v", err)
+	}
+	run := a.Run(states, generations)
+	PrintGrid(a.Decode(run.Final))
+	switch {
+	case run.Fixpoint():
+		fmt.Printf("Fixpoint after %v generations\n", run.CycleStart)
+	case run.CycleLength > 0:
+		fmt.Printf("Cycle of length %v from generation %v\n", run.CycleLength, run.CycleStart)
+	default:
+		fmt.Printf("No cycle within %v generations\n", run.Generations)
+	}
+	fmt.Printf("Alive cells: %v -> %v", CountState(states, 1), CountState(run.Final, 1))
 }
 
 func GetGridDimensions(grid [][]rune) (int, int) {
@@ -93,44 +187,3 @@ func PrintGrid(grid [][]rune) {
 		fmt.Println(row)
 	}
 }
-
-func CountNeighbors(grid [][]rune, j, i int, symbol rune) int {
-	count := 0
-
-	yLen, xLen := GetGridDimensions(grid)
-	if j != 0 {
-		// We can check the row above
-		if i != 0 && grid[j-1][i-1] == symbol {
-			count++
-		}
-		if grid[j-1][i] == symbol {
-			count++
-		}
-		if i < xLen-1 && grid[j-1][i+1] == symbol {
-			count++
-		}
-	}
-	if i != 0 && grid[j][i-1] == symbol {
-		count++
-	}
-	if i < xLen-1 && grid[j][i+1] == symbol {
-		count++
-	}
-	if j < yLen-1 {
-		// We can check the row above
-		if i != 0 && grid[j+1][i-1] == symbol {
-			count++
-		}
-		if grid[j+1][i] == symbol {
-			count++
-		}
-		if i < xLen-1 && grid[j+1][i+1] == symbol {
-			count++
-		}
-	}
-	return count
-}
-
-func CanForkliftPaperRole(count int) bool {
-	return count < 4
-}
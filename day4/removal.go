@@ -0,0 +1,125 @@
+package main
+
+type cell struct {
+	j int
+	i int
+}
+
+// Removal records when every roll was forklifted.
+type Removal struct {
+	// Waves holds, for every cell, the iteration (from 1) in which it was
+	// removed, or 0 if it never was
+	Waves [][]int
+	// WaveCounts[k] is how many rolls were removed in iteration k + 1
+	WaveCounts []int
+	// Rolls is how many rolls there were before any removal
+	Rolls int
+	// Stopped is true if the removal hit its iteration limit while rolls
+	// could still be removed
+	Stopped bool
+}
+
+// First returns how many rolls could be removed right away, the answer to
+// part 1.
+func (r Removal) First() int {
+	if len(r.WaveCounts) == 0 {
+		return 0
+	}
+	return r.WaveCounts[0]
+}
+
+func (r Removal) Remaining() int {
+	return r.Rolls - r.Total()
+}
+
+func (r Removal) Total() int {
+	total := 0
+	for _, count := range r.WaveCounts {
+		total += count
+	}
+	return total
+}
+
+func (r Removal) Iterations() int {
+	return len(r.WaveCounts)
+}
+
+/*
+RemoveRolls forklifts paper rolls until none can be removed, or for at most maxWaves iterations when maxWaves > 0,
+marking removed rolls with removed, and returns when each roll was removed.
+
+Removing a roll only changes the neighbor counts of the rolls around it, so instead of rescanning the grid, the
+counts are computed once and kept up to date. Rolls are removed in waves: the first wave is every roll that can be
+forklifted at the start, and every next wave is made of the neighbors of the previous wave that just became
+removable. A roll that is not next to a removed roll keeps its count, so it cannot become removable, and a wave is
+exactly what one full rescan of the grid would remove. Every roll is removed at most once and every removal
+touches its neighborhood once, so this is O(cells * neighborhood size).
+*/
+func RemoveRolls(grid [][]rune, rule Rule, removed rune, maxWaves int) Removal {
+	yLen, xLen := GetGridDimensions(grid)
+	counts := make([][]int, yLen)
+	queued := make([][]bool, yLen)
+	waves := make([][]int, yLen)
+	// touched[j][i] is the last wave that changed the count of a cell
+	touched := make([][]int, yLen)
+	wave := make([]cell, 0)
+	rolls := 0
+	for j := 0; j < yLen; j++ {
+		counts[j] = make([]int, xLen)
+		queued[j] = make([]bool, xLen)
+		waves[j] = make([]int, xLen)
+		touched[j] = make([]int, xLen)
+		for i := 0; i < xLen; i++ {
+			if grid[j][i] != rule.Symbol {
+				continue
+			}
+			rolls++
+			counts[j][i] = rule.CountNeighbors(grid, j, i)
+			if rule.Removable(counts[j][i]) {
+				queued[j][i] = true
+				wave = append(wave, cell{j: j, i: i})
+			}
+		}
+	}
+	// a removed cell lowers the count of every cell that has it as a neighbor,
+	// which is the cell at the opposite offset
+	reversed := make([]Offset, len(rule.Offsets))
+	for k, o := range rule.Offsets {
+		reversed[k] = Offset{DJ: -o.DJ, DI: -o.DI}
+	}
+
+	waveCounts := make([]int, 0)
+	for len(wave) > 0 && (maxWaves <= 0 || len(waveCounts) < maxWaves) {
+		waveCounts = append(waveCounts, len(wave))
+		waveNum := len(waveCounts)
+		for _, c := range wave {
+			grid[c.j][c.i] = removed
+			waves[c.j][c.i] = waveNum
+		}
+		changed := make([]cell, 0)
+		for _, c := range wave {
+			for _, o := range reversed {
+				j, i, ok := rule.Neighbor(yLen, xLen, c.j, c.i, o)
+				if !ok || grid[j][i] != rule.Symbol || queued[j][i] {
+					continue
+				}
+				counts[j][i]--
+				if touched[j][i] != waveNum {
+					touched[j][i] = waveNum
+					changed = append(changed, cell{j: j, i: i})
+				}
+			}
+		}
+		// only check once the whole wave is counted, since a count can pass
+		// through the threshold for comparisons like ==
+		nextWave := make([]cell, 0)
+		for _, c := range changed {
+			if rule.Removable(counts[c.j][c.i]) {
+				queued[c.j][c.i] = true
+				nextWave = append(nextWave, c)
+			}
+		}
+		wave = nextWave
+	}
+	return Removal{Waves: waves, WaveCounts: waveCounts, Rolls: rolls, Stopped: len(wave) > 0}
+}
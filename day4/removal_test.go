@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const exampleGrid = `..@@.@@@@.
+@@@.@.@.@@
+@@@@@.@.@@
+@.@@@@..@.
+@@.@@@@.@@
+.@@@@@@@.@
+.@.@.@.@@@
+@.@@@.@@@@
+.@@@@@@@@.
+@.@.@@@.@.`
+
+func readExampleGrid() [][]rune {
+	grid := make([][]rune, 0)
+	for _, row := range strings.Split(exampleGrid, "\n") {
+		grid = append(grid, []rune(row))
+	}
+	return grid
+}
+
+func Test_RemoveRolls(t *testing.T) {
+	removal := RemoveRolls(readExampleGrid(), PaperRollRule(), 'x', 0)
+	expected := []int{13, 12, 7, 5, 2, 1, 1, 1, 1}
+	if !slices.Equal(removal.WaveCounts, expected) {
+		t.Errorf("Expected %v, got %v", expected, removal.WaveCounts)
+	}
+	if removal.Total() != 43 {
+		t.Errorf("Expected 43, got %v", removal.Total())
+	}
+	if removal.Waves[0][2] != 1 || removal.Waves[2][3] != 7 || removal.Waves[4][4] != 0 {
+		t.Errorf("Unexpected waves %v %v %v", removal.Waves[0][2], removal.Waves[2][3], removal.Waves[4][4])
+	}
+	if removal.First() != 13 || removal.Remaining() != 71-43 || removal.Stopped {
+		t.Errorf("Unexpected removal %v %v %v", removal.First(), removal.Remaining(), removal.Stopped)
+	}
+}
+
+func Test_RemoveRolls_maxWaves(t *testing.T) {
+	data := []struct {
+		name      string
+		maxWaves  int
+		removed   int
+		remaining int
+		stopped   bool
+	}{
+		{"one", 1, 13, 58, true},
+		{"three", 3, 32, 39, true},
+		{"exact", 9, 43, 28, false},
+		{"more", 20, 43, 28, false},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			grid := readExampleGrid()
+			removal := RemoveRolls(grid, PaperRollRule(), 'x', d.maxWaves)
+			if removal.Total() != d.removed || removal.Remaining() != d.remaining || removal.Stopped != d.stopped {
+				t.Errorf("Expected %v %v %v, got %v %v %v", d.removed, d.remaining, d.stopped, removal.Total(), removal.Remaining(), removal.Stopped)
+			}
+			bitGrid, err := ReadBitGrid(bufio.NewScanner(strings.NewReader(exampleGrid)), '@')
+			if err != nil {
+				t.Fatal(err)
+			}
+			bitRemoval := RemoveRollsBits(bitGrid, PaperRollRule(), 2, d.maxWaves)
+			if !slices.Equal(bitRemoval.WaveCounts, removal.WaveCounts) || bitRemoval.Remaining() != d.remaining || bitRemoval.Stopped != d.stopped {
+				t.Errorf("Expected %v %v %v, got %v %v %v", removal.WaveCounts, d.remaining, d.stopped, bitRemoval.WaveCounts, bitRemoval.Remaining(), bitRemoval.Stopped)
+			}
+			if bitGrid.Count() != d.remaining {
+				t.Errorf("Expected %v, got %v", d.remaining, bitGrid.Count())
+			}
+		})
+	}
+}
+
+// rescanRemove is the plain algorithm: rescan the whole grid every
+// iteration and remove everything removable at once.
+func rescanRemove(grid [][]rune, rule Rule) [][]int {
+	yLen, xLen := GetGridDimensions(grid)
+	waves := make([][]int, yLen)
+	for j := range waves {
+		waves[j] = make([]int, xLen)
+	}
+	for wave := 1; ; wave++ {
+		removable := make([]cell, 0)
+		for j := 0; j < yLen; j++ {
+			for i := 0; i < xLen; i++ {
+				if grid[j][i] == rule.Symbol && rule.Removable(rule.CountNeighbors(grid, j, i)) {
+					removable = append(removable, cell{j: j, i: i})
+				}
+			}
+		}
+		if len(removable) == 0 {
+			return waves
+		}
+		for _, c := range removable {
+			grid[c.j][c.i] = 'x'
+			waves[c.j][c.i] = wave
+		}
+	}
+}
+
+func Test_RemoveRolls_rules(t *testing.T) {
+	custom, _ := ParseOffsets("0,1;1,1;2,0;-1,-2")
+	data := []struct {
+		name   string
+		mutate func(r *Rule)
+	}{
+		{"paper_rolls", func(r *Rule) {}},
+		{"von_neumann", func(r *Rule) { r.Offsets = VonNeumannNeighborhood(1); r.Threshold = 2 }},
+		{"moore_radius_2", func(r *Rule) { r.Offsets = MooreNeighborhood(2); r.Threshold = 12 }},
+		{"toroidal", func(r *Rule) { r.Edges = Toroidal }},
+		{"equal", func(r *Rule) { r.Compare = Equal; r.Threshold = 3 }},
+		{"greater", func(r *Rule) { r.Compare = GreaterThan; r.Threshold = 5 }},
+		{"custom_asymmetric", func(r *Rule) { r.Offsets = custom; r.Threshold = 2; r.Edges = Toroidal }},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			rule := PaperRollRule()
+			d.mutate(&rule)
+			expected := rescanRemove(readExampleGrid(), rule)
+			removal := RemoveRolls(readExampleGrid(), rule, 'x', 0)
+			for j := range expected {
+				if !slices.Equal(removal.Waves[j], expected[j]) {
+					t.Errorf("Row %v: expected %v, got %v", j, expected[j], removal.Waves[j])
+				}
+			}
+		})
+	}
+}
+
+func Test_Automaton(t *testing.T) {
+	data := []struct {
+		name        string
+		rule        string
+		grid        []string
+		cycleStart  int
+		cycleLength int
+		alive       int
+	}{
+		{"paper rolls", "paper-rolls", strings.Split(exampleGrid, "\n"), 9, 1, 71 - 43},
+		{"blinker", "B3/S23", []string{".....", "..@..", "..@..", "..@..", "....."}, 0, 2, 3},
+		{"block", "life", []string{"....", ".@@.", ".@@.", "...."}, 0, 1, 4},
+		{"dies out", "B3/S23", []string{"...", ".@.", "..."}, 1, 1, 0},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			rule, err := ParseLifeRule(d.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a, err := NewAutomaton(rule, PaperRollRule().Neighborhood, ".@")
+			if err != nil {
+				t.Fatal(err)
+			}
+			grid := make([][]rune, len(d.grid))
+			for j, row := range d.grid {
+				grid[j] = []rune(row)
+			}
+			states, err := a.Encode(grid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			run := a.Run(states, 100)
+			if run.CycleStart != d.cycleStart || run.CycleLength != d.cycleLength {
+				t.Errorf("Expected cycle of %v from %v, got %v from %v", d.cycleLength, d.cycleStart, run.CycleLength, run.CycleStart)
+			}
+			if alive := CountState(run.Final, 1); alive != d.alive {
+				t.Errorf("Expected %v, got %v", d.alive, alive)
+			}
+		})
+	}
+}
+
+func Test_ParseLifeRule(t *testing.T) {
+	rule, err := ParseLifeRule("brians-brain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule.States != 3 || !rule.Birth[2] || len(rule.Survive) != 0 {
+		t.Errorf("Unexpected rule %v", rule)
+	}
+	if rule.Next(1, 2) != 2 || rule.Next(2, 0) != 0 || rule.Next(0, 2) != 1 {
+		t.Errorf("Unexpected transitions %v %v %v", rule.Next(1, 2), rule.Next(2, 0), rule.Next(0, 2))
+	}
+	rule, err = ParseLifeRule("B3,10-12/S2,3/C256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, count := range []int{3, 10, 11, 12} {
+		if !rule.Birth[count] {
+			t.Errorf("Expected birth on %v", count)
+		}
+	}
+	if len(rule.Birth) != 4 || len(rule.Survive) != 2 || !rule.Survive[2] || !rule.Survive[3] || rule.States != 256 {
+		t.Errorf("Unexpected rule %v", rule)
+	}
+	if rule.Next(255, 0) != 0 || rule.Next(254, 0) != 255 {
+		t.Errorf("Unexpected transitions %v %v", rule.Next(255, 0), rule.Next(254, 0))
+	}
+	rule, err = ParseLifeRule("B10,/S")
+	if err != nil || len(rule.Birth) != 1 || !rule.Birth[10] {
+		t.Errorf("Unexpected rule %v, %v", rule, err)
+	}
+	for _, bad := range []string{"S23", "B3", "B3/S2a", "B3/S23/C1", "B3/S23/C257", "B3/S23/X3", "B3,x/S2", "B5-3/S", "B1-2-3/S", "B1,99999999/S"} {
+		if _, err := ParseLifeRule(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func Test_RemoveRollsBits(t *testing.T) {
+	custom, _ := ParseOffsets("0,1;1,1;2,0;-1,-2")
+	// wide enough for rows to span several words
+	wide := make([][]rune, 7)
+	for j := range wide {
+		wide[j] = make([]rune, 150)
+		for i := range wide[j] {
+			wide[j][i] = '.'
+			if (j*31+i*17)%5 < 3 {
+				wide[j][i] = '@'
+			}
+		}
+	}
+	data := []struct {
+		name   string
+		grid   [][]rune
+		mutate func(r *Rule)
+	}{
+		{"paper_rolls", readExampleGrid(), func(r *Rule) {}},
+		{"von_neumann", readExampleGrid(), func(r *Rule) { r.Offsets = VonNeumannNeighborhood(1); r.Threshold = 2 }},
+		{"moore_radius_2", readExampleGrid(), func(r *Rule) { r.Offsets = MooreNeighborhood(2); r.Threshold = 12 }},
+		{"toroidal", readExampleGrid(), func(r *Rule) { r.Edges = Toroidal }},
+		{"equal", readExampleGrid(), func(r *Rule) { r.Compare = Equal; r.Threshold = 3 }},
+		{"greater", readExampleGrid(), func(r *Rule) { r.Compare = GreaterThan; r.Threshold = 5 }},
+		{"custom_asymmetric", readExampleGrid(), func(r *Rule) { r.Offsets = custom; r.Threshold = 2; r.Edges = Toroidal }},
+		{"wide", wide, func(r *Rule) {}},
+		{"wide_toroidal", wide, func(r *Rule) { r.Edges = Toroidal; r.Offsets = MooreNeighborhood(2); r.Threshold = 14 }},
+	}
+	for _, d := range data {
+		for _, workers := range []int{1, 3} {
+			t.Run(fmt.Sprintf("%v_%v", d.name, workers), func(t *testing.T) {
+				rule := PaperRollRule()
+				d.mutate(&rule)
+				text := make([]string, len(d.grid))
+				runes := make([][]rune, len(d.grid))
+				for j, row := range d.grid {
+					text[j] = string(row)
+					runes[j] = slices.Clone(row)
+				}
+				grid, err := ReadBitGrid(bufio.NewScanner(strings.NewReader(strings.Join(text, "\n"))), rule.Symbol)
+				if err != nil {
+					t.Fatal(err)
+				}
+				expected := RemoveRolls(runes, rule, 'x', 0)
+				removal := RemoveRollsBits(grid, rule, workers, 0)
+				if !slices.Equal(removal.WaveCounts, expected.WaveCounts) {
+					t.Errorf("Expected %v, got %v", expected.WaveCounts, removal.WaveCounts)
+				}
+				for j := range runes {
+					for i, c := range runes[j] {
+						if grid.Get(j, i) != (c == rule.Symbol) {
+							t.Errorf("Cell %v,%v: expected remaining %v", j, i, !grid.Get(j, i))
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func Test_RemovalGIF(t *testing.T) {
+	grid := readExampleGrid()
+	removal := RemoveRolls(grid, PaperRollRule(), 'x', 0)
+	anim, err := RemovalGIF(grid, removal, '@', 2, DefaultGIFColors(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anim.Image) != 10 {
+		t.Fatalf("Expected 10, got %v", len(anim.Image))
+	}
+	removedEarly := 0
+	for k, img := range anim.Image {
+		counts := make([]int, 4)
+		for _, kind := range img.Pix {
+			counts[kind]++
+		}
+		expectedNow := 0
+		if k > 0 {
+			expectedNow = removal.WaveCounts[k-1]
+		}
+		if counts[gifRemovedNow] != 4*expectedNow || counts[gifRemovedEarly] != 4*removedEarly || counts[gifPaper] != 4*(71-removedEarly-expectedNow) {
+			t.Errorf("Frame %v: unexpected cell counts %v", k, counts)
+		}
+		removedEarly += expectedNow
+	}
+	if _, err := ParseColor("#12345g"); err == nil {
+		t.Errorf("Expected an error for #12345g")
+	}
+}
+
+func Test_WriteHeatmap(t *testing.T) {
+	grid := readExampleGrid()
+	removal := RemoveRolls(grid, PaperRollRule(), 'x', 1)
+	path := filepath.Join(t.TempDir(), "heatmap.png")
+	err := WriteHeatmap(path, grid, removal, '@', 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size.X != 30 || size.Y != 30 {
+		t.Fatalf("Expected 30x30, got %vx%v", size.X, size.Y)
+	}
+	data := []struct {
+		name     string
+		j        int
+		i        int
+		expected color.RGBA
+	}{
+		{"empty", 0, 0, heatmapEmpty},
+		{"first_wave", 0, 2, heatmapFirst},
+		{"remaining", 1, 1, heatmapRemaining},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			for _, corner := range [][2]int{{0, 0}, {2, 2}} {
+				got := color.RGBAModel.Convert(img.At(d.i*3+corner[0], d.j*3+corner[1]))
+				if got != d.expected {
+					t.Errorf("Expected %v, got %v", d.expected, got)
+				}
+			}
+		})
+	}
+
+	// with every wave, the last one gets heatmapLast
+	grid = readExampleGrid()
+	removal = RemoveRolls(grid, PaperRollRule(), 'x', 0)
+	err = WriteHeatmap(path, grid, removal, '@', 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err = os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err = png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for j, row := range removal.Waves {
+		for i, wave := range row {
+			got := color.RGBAModel.Convert(img.At(i, j))
+			if wave == removal.Iterations() && got != heatmapLast {
+				t.Errorf("Expected %v at row %v, column %v, got %v", heatmapLast, j, i, got)
+			}
+			if wave == 1 && got != heatmapFirst {
+				t.Errorf("Expected %v at row %v, column %v, got %v", heatmapFirst, j, i, got)
+			}
+		}
+	}
+	if err := WriteHeatmap(path, grid, removal, '@', 0); err == nil {
+		t.Errorf("Expected an error for cell size 0")
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Offset is the position of a neighbor relative to a cell.
+type Offset struct {
+	DJ int
+	DI int
+}
+
+// MooreNeighborhood is every cell within radius in both directions, e.g.
+// the 8 surrounding cells for radius 1.
+func MooreNeighborhood(radius int) []Offset {
+	offsets := make([]Offset, 0)
+	for dj := -radius; dj <= radius; dj++ {
+		for di := -radius; di <= radius; di++ {
+			if dj != 0 || di != 0 {
+				offsets = append(offsets, Offset{DJ: dj, DI: di})
+			}
+		}
+	}
+	return offsets
+}
+
+// VonNeumannNeighborhood is every cell within radius steps up, down, left
+// or right, e.g. the 4 adjacent cells for radius 1.
+func VonNeumannNeighborhood(radius int) []Offset {
+	offsets := make([]Offset, 0)
+	for _, o := range MooreNeighborhood(radius) {
+		if abs(o.DJ)+abs(o.DI) <= radius {
+			offsets = append(offsets, o)
+		}
+	}
+	return offsets
+}
+
+// ParseOffsets parses a custom neighborhood written as "dj,di;dj,di;...".
+func ParseOffsets(s string) ([]Offset, error) {
+	offsets := make([]Offset, 0)
+	for _, pair := range strings.Split(s, ";") {
+		parts := strings.Split(strings.TrimSpace(pair), ",")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Offset must look like dj,di. Got %q", pair)
+		}
+		dj, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, err
+		}
+		di, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, err
+		}
+		offsets = append(offsets, Offset{DJ: dj, DI: di})
+	}
+	return offsets, nil
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+type Edge int
+
+const (
+	// Bounded ignores neighbors outside the grid
+	Bounded Edge = iota
+	// Toroidal wraps neighbors around to the opposite side of the grid
+	Toroidal
+)
+
+type Comparison string
+
+const (
+	LessThan       Comparison = "<"
+	LessOrEqual    Comparison = "<="
+	Equal          Comparison = "=="
+	GreaterOrEqual Comparison = ">="
+	GreaterThan    Comparison = ">"
+)
+
+func ParseComparison(s string) (Comparison, error) {
+	switch c := Comparison(s); c {
+	case LessThan, LessOrEqual, Equal, GreaterOrEqual, GreaterThan:
+		return c, nil
+	}
+	return "", fmt.Errorf("Comparison must be one of <, <=, ==, >=, >. Got %q", s)
+}
+
+// Neighborhood is the set of cells around a cell that count as its
+// neighbors, and what happens to the ones past the edge of the grid.
+type Neighborhood struct {
+	Offsets []Offset
+	Edges   Edge
+}
+
+// Rule decides which cells get removed: a cell holding Symbol is removed
+// when the number of its neighbors holding Symbol compares to Threshold.
+type Rule struct {
+	Neighborhood
+	Symbol    rune
+	Compare   Comparison
+	Threshold int
+}
+
+// PaperRollRule is the puzzle's rule: a roll can be forklifted when fewer
+// than 4 of the 8 surrounding cells hold rolls.
+func PaperRollRule() Rule {
+	return Rule{
+		Neighborhood: Neighborhood{Offsets: MooreNeighborhood(1), Edges: Bounded},
+		Symbol:       '@',
+		Compare:      LessThan,
+		Threshold:    4,
+	}
+}
+
+// Neighbor returns the cell at offset o from (j, i) in a grid of yLen by xLen
+// cells, and false if it falls outside a bounded grid.
+func (n Neighborhood) Neighbor(yLen, xLen, j, i int, o Offset) (int, int, bool) {
+	j, i = j+o.DJ, i+o.DI
+	if n.Edges == Toroidal {
+		return ((j % yLen) + yLen) % yLen, ((i % xLen) + xLen) % xLen, true
+	}
+	return j, i, j >= 0 && j < yLen && i >= 0 && i < xLen
+}
+
+func (r Rule) CountNeighbors(grid [][]rune, j, i int) int {
+	count := 0
+	yLen, xLen := GetGridDimensions(grid)
+	for _, o := range r.Offsets {
+		jj, ii, ok := r.Neighbor(yLen, xLen, j, i, o)
+		if ok && grid[jj][ii] == r.Symbol {
+			count++
+		}
+	}
+	return count
+}
+
+func (r Rule) Removable(count int) bool {
+	switch r.Compare {
+	case LessThan:
+		return count < r.Threshold
+	case LessOrEqual:
+		return count <= r.Threshold
+	case Equal:
+		return count == r.Threshold
+	case GreaterOrEqual:
+		return count >= r.Threshold
+	case GreaterThan:
+		return count > r.Threshold
+	}
+	return false
+}
+
+// newNeighborhood builds a neighborhood from the command line options.
+// offsets, when set, overrides shape and radius.
+func newNeighborhood(shape string, radius int, offsets string, wrap bool) (Neighborhood, error) {
+	var n Neighborhood
+	if radius < 1 {
+		return n, fmt.Errorf("Radius must be >= 1. Got %v", radius)
+	}
+	switch {
+	case offsets != "":
+		custom, err := ParseOffsets(offsets)
+		if err != nil {
+			return n, err
+		}
+		n.Offsets = custom
+	case shape == "moore":
+		n.Offsets = MooreNeighborhood(radius)
+	case shape == "vonneumann":
+		n.Offsets = VonNeumannNeighborhood(radius)
+	default:
+		return n, fmt.Errorf("Neighborhood must be moore or vonneumann. Got %q", shape)
+	}
+	if wrap {
+		n.Edges = Toroidal
+	}
+	return n, nil
+}
+
+// newRule builds a rule from the command line options.
+func newRule(neighborhood Neighborhood, symbol string, compare string, threshold int) (Rule, error) {
+	rule := PaperRollRule()
+	rule.Neighborhood = neighborhood
+	symbols := []rune(symbol)
+	if len(symbols) != 1 {
+		return Rule{}, fmt.Errorf("Symbol must be a single character. Got %q", symbol)
+	}
+	rule.Symbol = symbols[0]
+	c, err := ParseComparison(compare)
+	if err != nil {
+		return Rule{}, err
+	}
+	rule.Compare = c
+	rule.Threshold = threshold
+	return rule, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+)
+
+const waveSymbols = "123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// waveSymbol returns the character for a wave: 1-9, then a-z, then A-Z, and
+// '+' past that.
+func waveSymbol(wave int) rune {
+	if wave > len(waveSymbols) {
+		return '+'
+	}
+	return rune(waveSymbols[wave-1])
+}
+
+// WaveGrid returns a copy of grid where every removed roll is replaced by
+// the symbol of the wave it was removed in. Every other cell is unchanged.
+func WaveGrid(grid [][]rune, removal Removal) [][]rune {
+	yLen, xLen := GetGridDimensions(grid)
+	waveGrid := make([][]rune, yLen)
+	for j := 0; j < yLen; j++ {
+		waveGrid[j] = make([]rune, xLen)
+		for i := 0; i < xLen; i++ {
+			if wave := removal.Waves[j][i]; wave > 0 {
+				waveGrid[j][i] = waveSymbol(wave)
+			} else {
+				waveGrid[j][i] = grid[j][i]
+			}
+		}
+	}
+	return waveGrid
+}
+
+var (
+	heatmapEmpty     = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	heatmapRemaining = color.RGBA{R: 32, G: 32, B: 32, A: 255}
+	heatmapFirst     = color.RGBA{R: 255, G: 220, B: 0, A: 255}
+	heatmapLast      = color.RGBA{R: 160, G: 0, B: 40, A: 255}
+)
+
+// heatColor blends from heatmapFirst for the first wave to heatmapLast for
+// the last one.
+func heatColor(wave, lastWave int) color.RGBA {
+	t := 0.0
+	if lastWave > 1 {
+		t = float64(wave-1) / float64(lastWave-1)
+	}
+	blend := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t)
+	}
+	return color.RGBA{
+		R: blend(heatmapFirst.R, heatmapLast.R),
+		G: blend(heatmapFirst.G, heatmapLast.G),
+		B: blend(heatmapFirst.B, heatmapLast.B),
+		A: 255,
+	}
+}
+
+// WriteHeatmap draws every cell as a cellSize square: removed rolls are
+// colored by wave, rolls still present are dark and everything else is white.
+func WriteHeatmap(path string, grid [][]rune, removal Removal, symbol rune, cellSize int) error {
+	if cellSize < 1 {
+		return fmt.Errorf("Cell size must be >= 1. Got %v", cellSize)
+	}
+	yLen, xLen := GetGridDimensions(grid)
+	img := image.NewRGBA(image.Rect(0, 0, xLen*cellSize, yLen*cellSize))
+	for j := 0; j < yLen; j++ {
+		for i := 0; i < xLen; i++ {
+			c := heatmapEmpty
+			if wave := removal.Waves[j][i]; wave > 0 {
+				c = heatColor(wave, removal.Iterations())
+			} else if grid[j][i] == symbol {
+				c = heatmapRemaining
+			}
+			for y := j * cellSize; y < (j+1)*cellSize; y++ {
+				for x := i * cellSize; x < (i+1)*cellSize; x++ {
+					img.SetRGBA(x, y, c)
+				}
+			}
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(f, img)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
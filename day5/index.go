@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"math/big"
+	"os"
+)
+
+/*
+The index file holds the consolidated ranges of an IntervalSet, so they can be loaded without parsing and
+consolidating the range section again. All numbers are little endian.
+
+	offset  size  field
+	0       8     magic "AOC25IDX"
+	8       4     version
+	12      4     flags, bit 0 set if the ranges were coalesced
+	16      8     number of ranges n
+	24      4     CRC-32 (IEEE) of the ranges
+	28      4     reserved, 0
+	32      16n   ranges, each as lower then upper, both int64
+
+The version is bumped on every change to the layout, and files with any other version are refused rather than
+guessed at.
+*/
+const (
+	indexMagic      = "AOC25IDX"
+	indexVersion    = 1
+	indexHeaderSize = 32
+	indexRangeSize  = 16
+
+	indexFlagCoalesced = 1 << 0
+)
+
+// EncodeIndex serializes the set into the index format.
+func EncodeIndex(set *IntervalSet) []byte {
+	buf := make([]byte, indexHeaderSize+indexRangeSize*len(set.ranges))
+	body := buf[indexHeaderSize:]
+	for k, r := range set.ranges {
+		binary.LittleEndian.PutUint64(body[k*indexRangeSize:], uint64(r.lower))
+		binary.LittleEndian.PutUint64(body[k*indexRangeSize+8:], uint64(r.upper))
+	}
+	var flags uint32
+	if set.coalesce {
+		flags |= indexFlagCoalesced
+	}
+	copy(buf, indexMagic)
+	binary.LittleEndian.PutUint32(buf[8:], indexVersion)
+	binary.LittleEndian.PutUint32(buf[12:], flags)
+	binary.LittleEndian.PutUint64(buf[16:], uint64(len(set.ranges)))
+	binary.LittleEndian.PutUint32(buf[24:], crc32.ChecksumIEEE(body))
+	return buf
+}
+
+/*
+DecodeIndex loads a set from the index format.
+
+The header, size and checksum are checked, and so is that the ranges are sorted, disjoint and not empty, since the
+lookups rely on it. Consolidation is skipped: a valid index already holds consolidated ranges.
+*/
+func DecodeIndex(buf []byte) (*IntervalSet, error) {
+	if len(buf) < indexHeaderSize || string(buf[:8]) != indexMagic {
+		return nil, fmt.Errorf("Not a range index")
+	}
+	if version := binary.LittleEndian.Uint32(buf[8:]); version != indexVersion {
+		return nil, fmt.Errorf("Unsupported range index version %v. Expected %v", version, indexVersion)
+	}
+	flags := binary.LittleEndian.Uint32(buf[12:])
+	n := binary.LittleEndian.Uint64(buf[16:])
+	body := buf[indexHeaderSize:]
+	if n > uint64(len(body)/indexRangeSize) || uint64(len(body)) != n*indexRangeSize {
+		return nil, fmt.Errorf("Range index holds %v bytes of ranges. Expected %v ranges", len(body), n)
+	}
+	if crc := crc32.ChecksumIEEE(body); crc != binary.LittleEndian.Uint32(buf[24:]) {
+		return nil, fmt.Errorf("Range index checksum mismatch")
+	}
+	set := &IntervalSet{ranges: make([]Range, n), span: new(big.Int), coalesce: flags&indexFlagCoalesced != 0}
+	for k := range set.ranges {
+		lower := int(int64(binary.LittleEndian.Uint64(body[k*indexRangeSize:])))
+		upper := int(int64(binary.LittleEndian.Uint64(body[k*indexRangeSize+8:])))
+		if lower > upper || (k > 0 && lower <= set.ranges[k-1].upper) {
+			return nil, fmt.Errorf("Range index range # %v [%v - %v] is empty or out of order", k, lower, upper)
+		}
+		set.ranges[k] = NewRange(lower, upper)
+		set.span.Add(set.span, set.ranges[k].RangeLen())
+	}
+	return set, nil
+}
+
+func WriteIndex(path string, set *IntervalSet) error {
+	return os.WriteFile(path, EncodeIndex(set), 0o644)
+}
+
+func ReadIndex(path string) (*IntervalSet, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	set, err := DecodeIndex(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return set, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func Test_Index(t *testing.T) {
+	for _, set := range []*IntervalSet{
+		NewIntervalSet([]Range{NewRange(3, 5), NewRange(10, 14), NewRange(16, 20), NewRange(12, 18)}),
+		NewCoalescedIntervalSet([]Range{NewRange(-7, -3), NewRange(-2, 0), NewRange(1<<60, 1<<61)}),
+		NewIntervalSet(nil),
+	} {
+		path := filepath.Join(t.TempDir(), "ranges.idx")
+		err := WriteIndex(path, set)
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := ReadIndex(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(loaded.Ranges(), set.Ranges()) || loaded.Span().Cmp(set.Span()) != 0 || loaded.coalesce != set.coalesce {
+			t.Errorf("Expected %v, got %v", set, loaded)
+		}
+	}
+}
+
+func Test_DecodeIndex_invalid(t *testing.T) {
+	valid := EncodeIndex(NewIntervalSet([]Range{NewRange(3, 5), NewRange(10, 20)}))
+	data := []struct {
+		name   string
+		mutate func(buf []byte) []byte
+	}{
+		{"short", func(buf []byte) []byte { return buf[:10] }},
+		{"magic", func(buf []byte) []byte { buf[0] = 'X'; return buf }},
+		{"version", func(buf []byte) []byte { binary.LittleEndian.PutUint32(buf[8:], 99); return buf }},
+		{"truncated", func(buf []byte) []byte { return buf[:len(buf)-1] }},
+		{"count", func(buf []byte) []byte { binary.LittleEndian.PutUint64(buf[16:], 1<<62); return buf }},
+		{"checksum", func(buf []byte) []byte { buf[len(buf)-1] ^= 1; return buf }},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			_, err := DecodeIndex(d.mutate(slices.Clone(valid)))
+			if err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
+
+func Test_Index_coalesced(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ranges.idx")
+	err := WriteIndex(path, NewIntervalSet([]Range{NewRange(3, 5), NewRange(6, 8), NewRange(10, 12)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ReadIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Len() != 3 {
+		t.Errorf("Expected 3, got %v", loaded.Len())
+	}
+	expected := []Range{NewRange(3, 8), NewRange(10, 12)}
+	if coalesced := loaded.Coalesced(); !slices.Equal(coalesced.Ranges(), expected) || coalesced.Span().Cmp(loaded.Span()) != 0 {
+		t.Errorf("Expected %v, got %v", expected, coalesced.Ranges())
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"math/big"
+	"sort"
+)
+
+// IntervalSet is a set of integers stored as sorted, disjoint ranges.
+type IntervalSet struct {
+	ranges []Range
+	// span is how many integers the set holds
+	span *big.Int
+	// coalesce merges ranges that touch, like 3-5 and 6-8, on top of the
+	// overlapping ones
+	coalesce bool
+}
+
+// NewIntervalSet builds a set holding every integer in any of the ranges.
+// Overlapping ranges are merged, and ranges is left untouched.
+func NewIntervalSet(ranges []Range) *IntervalSet {
+	return newIntervalSet(ranges, false)
+}
+
+// NewCoalescedIntervalSet is NewIntervalSet, but ranges that touch are
+// merged too. Sets built from it by set operations are coalesced as well.
+func NewCoalescedIntervalSet(ranges []Range) *IntervalSet {
+	return newIntervalSet(ranges, true)
+}
+
+func newIntervalSet(ranges []Range, coalesce bool) *IntervalSet {
+	copied := append([]Range(nil), ranges...)
+	var consolidated []Range
+	if coalesce {
+		consolidated = CoalesceRanges(copied)
+	} else {
+		consolidated = ConsolidateRanges(copied)
+	}
+	span := new(big.Int)
+	for _, r := range consolidated {
+		span.Add(span, r.RangeLen())
+	}
+	return &IntervalSet{ranges: consolidated, span: span, coalesce: coalesce}
+}
+
+/*
+Find returns the range holding num and its position in the set, or false if no range does.
+
+The ranges are sorted and disjoint, so the only candidate is the last range starting at or before num, which is
+found by binary search in O(log n).
+*/
+func (s *IntervalSet) Find(num int) (Range, int, bool) {
+	k := sort.Search(len(s.ranges), func(k int) bool {
+		return s.ranges[k].lower > num
+	}) - 1
+	if k < 0 || !s.ranges[k].InRange(num) {
+		return Range{}, -1, false
+	}
+	return s.ranges[k], k, true
+}
+
+func (s *IntervalSet) Contains(num int) bool {
+	_, _, ok := s.Find(num)
+	return ok
+}
+
+// Len returns the number of disjoint ranges in the set.
+func (s *IntervalSet) Len() int {
+	return len(s.ranges)
+}
+
+// Span returns how many integers the set holds.
+func (s *IntervalSet) Span() *big.Int {
+	return s.span
+}
+
+func (s *IntervalSet) Ranges() []Range {
+	return s.ranges
+}
+
+// Coalesced returns the set with touching ranges merged, or s itself if it
+// already is.
+func (s *IntervalSet) Coalesced() *IntervalSet {
+	if s.coalesce {
+		return s
+	}
+	return NewCoalescedIntervalSet(s.ranges)
+}
+
+// Union returns the integers in either set.
+func (s *IntervalSet) Union(other *IntervalSet) *IntervalSet {
+	return newIntervalSet(append(append([]Range(nil), s.ranges...), other.ranges...), s.coalesce)
+}
+
+// Intersect returns the integers in both sets. Both sets are walked together,
+// always moving past the range that ends first.
+func (s *IntervalSet) Intersect(other *IntervalSet) *IntervalSet {
+	result := make([]Range, 0)
+	for i, j := 0, 0; i < len(s.ranges) && j < len(other.ranges); {
+		a, b := s.ranges[i], other.ranges[j]
+		lower, upper := max(a.lower, b.lower), min(a.upper, b.upper)
+		if lower <= upper {
+			result = append(result, NewRange(lower, upper))
+		}
+		if a.upper < b.upper {
+			i++
+		} else {
+			j++
+		}
+	}
+	return newIntervalSet(result, s.coalesce)
+}
+
+/*
+Difference returns the integers in s that are not in other.
+
+Every range of s is cut by the ranges of other overlapping it, which are found by walking other alongside s. A range
+of other can overlap several ranges of s, so it is only skipped once it ends before the current range starts.
+*/
+func (s *IntervalSet) Difference(other *IntervalSet) *IntervalSet {
+	result := make([]Range, 0)
+	j := 0
+	for _, r := range s.ranges {
+		for j < len(other.ranges) && other.ranges[j].upper < r.lower {
+			j++
+		}
+		lower := r.lower
+		covered := false
+		for k := j; k < len(other.ranges) && other.ranges[k].lower <= r.upper; k++ {
+			cut := other.ranges[k]
+			if cut.lower > lower {
+				result = append(result, NewRange(lower, cut.lower-1))
+			}
+			if cut.upper >= r.upper {
+				covered = true
+				break
+			}
+			lower = cut.upper + 1
+		}
+		if !covered {
+			result = append(result, NewRange(lower, r.upper))
+		}
+	}
+	return newIntervalSet(result, s.coalesce)
+}
+
+// SymmetricDifference returns the integers in exactly one of the sets.
+func (s *IntervalSet) SymmetricDifference(other *IntervalSet) *IntervalSet {
+	return s.Difference(other).Union(other.Difference(s))
+}
+
+// Complement returns the integers within bounds that are not in the set,
+// i.e. the gaps between its ranges.
+func (s *IntervalSet) Complement(bounds Range) *IntervalSet {
+	return newIntervalSet([]Range{bounds}, s.coalesce).Difference(s)
+}
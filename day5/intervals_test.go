@@ -0,0 +1,103 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func Test_IntervalSet(t *testing.T) {
+	// the puzzle example
+	set := NewIntervalSet([]Range{NewRange(3, 5), NewRange(10, 14), NewRange(16, 20), NewRange(12, 18)})
+	expected := []Range{NewRange(3, 5), NewRange(10, 20)}
+	if !slices.Equal(set.Ranges(), expected) {
+		t.Errorf("Expected %v, got %v", expected, set.Ranges())
+	}
+	if set.Len() != 2 || set.Span().Int64() != 14 {
+		t.Errorf("Expected 2 and 14, got %v and %v", set.Len(), set.Span())
+	}
+	count := 0
+	for _, item := range []int{1, 5, 8, 11, 17, 32} {
+		if set.Contains(item) {
+			count++
+		}
+	}
+	if count != 3 {
+		t.Errorf("Expected 3, got %v", count)
+	}
+	data := []struct {
+		name  string
+		num   int
+		index int
+	}{
+		{"below", 2, -1},
+		{"first lower", 3, 0},
+		{"first upper", 5, 0},
+		{"gap", 9, -1},
+		{"merged", 15, 1},
+		{"last upper", 20, 1},
+		{"above", 21, -1},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			r, index, ok := set.Find(d.num)
+			if index != d.index || ok != (d.index >= 0) {
+				t.Errorf("Expected %v, got %v", d.index, index)
+			}
+			if ok && r != expected[index] {
+				t.Errorf("Expected %v, got %v", expected[index], r)
+			}
+			if set.Contains(d.num) != ok {
+				t.Errorf("Expected %v, got %v", ok, set.Contains(d.num))
+			}
+		})
+	}
+	empty := NewIntervalSet(nil)
+	if empty.Contains(0) || empty.Len() != 0 || empty.Span().Sign() != 0 {
+		t.Errorf("Expected an empty set")
+	}
+}
+
+// bruteSet lists the integers of a set between lower and upper.
+func bruteSet(s *IntervalSet, lower, upper int) []int {
+	nums := make([]int, 0)
+	for num := lower; num <= upper; num++ {
+		if s.Contains(num) {
+			nums = append(nums, num)
+		}
+	}
+	return nums
+}
+
+func Test_IntervalSet_algebra(t *testing.T) {
+	a := NewIntervalSet([]Range{NewRange(3, 5), NewRange(10, 14), NewRange(16, 20), NewRange(12, 18), NewRange(25, 25)})
+	b := NewIntervalSet([]Range{NewRange(1, 3), NewRange(5, 11), NewRange(13, 13), NewRange(19, 30)})
+	data := []struct {
+		name     string
+		result   *IntervalSet
+		expected func(inA, inB bool) bool
+	}{
+		{"union", a.Union(b), func(inA, inB bool) bool { return inA || inB }},
+		{"intersect", a.Intersect(b), func(inA, inB bool) bool { return inA && inB }},
+		{"difference", a.Difference(b), func(inA, inB bool) bool { return inA && !inB }},
+		{"reverse difference", b.Difference(a), func(inA, inB bool) bool { return inB && !inA }},
+		{"symmetric difference", a.SymmetricDifference(b), func(inA, inB bool) bool { return inA != inB }},
+		{"complement", a.Complement(NewRange(0, 22)), func(inA, inB bool) bool { return !inA }},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			expected := make([]int, 0)
+			for num := -5; num <= 35; num++ {
+				if d.expected(a.Contains(num), b.Contains(num)) && (d.name != "complement" || (num >= 0 && num <= 22)) {
+					expected = append(expected, num)
+				}
+			}
+			got := bruteSet(d.result, -5, 35)
+			if !slices.Equal(got, expected) {
+				t.Errorf("Expected %v, got %v", expected, got)
+			}
+			if d.result.Span().Int64() != int64(len(expected)) {
+				t.Errorf("Expected %v, got %v", len(expected), d.result.Span())
+			}
+		})
+	}
+}
@@ -2,12 +2,19 @@ package main
 
 import (
 	"bufio"
+	"cmp"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"math"
+	"math/big"
+	"net/http"
 	"os"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Range struct {
@@ -23,64 +30,163 @@ func (r Range) InRange(num int) bool {
 	return r.lower <= num && r.upper >= num
 }
 
-func (r Range) RangeLen() int {
-	return (r.upper - r.lower) + 1
+// RangeLen returns how many integers the range holds. It is a big.Int since
+// a range over all of int64 holds 2^64 of them.
+func (r Range) RangeLen() *big.Int {
+	rangeLen := new(big.Int).Sub(big.NewInt(int64(r.upper)), big.NewInt(int64(r.lower)))
+	return rangeLen.Add(rangeLen, big.NewInt(1))
 }
 
 func main() {
+	op := flag.String("op", "", "combine the ranges with another list instead: union, intersect, difference, symdiff or complement")
+	otherPath := flag.String("other", "", "file holding the other list of ranges for -op")
+	bounds := flag.String("bounds", "", "bounding range for -op complement in any range notation (default from the lowest to the highest ID)")
+	coalesce := flag.Bool("coalesce", false, "also merge ranges that touch, like 3-5 and 6-8")
+	itemsOut := flag.String("items-out", "", "write whether every ingredient is fresh or spoiled to this file (- for stdout)")
+	indexPath := flag.String("index", "", "load the ranges from this index file instead of stdin, which then holds only the ingredients")
+	writeIndexPath := flag.String("write-index", "", "write the consolidated ranges to this index file")
+	serve := flag.String("serve", "", "serve freshness lookups over HTTP on this address, e.g. localhost:8080")
+	rangesPath := flag.String("ranges", "", "range file or index file to serve with -serve")
+	reloadInterval := flag.Duration("reload-interval", 0, "with -serve, reload the range file when it changes, checking this often (0 to only reload on POST /reload)")
+	flag.Parse()
+	if *serve != "" {
+		main_serve(*serve, *rangesPath, *coalesce, *reloadInterval)
+		return
+	}
 	fmt.Println("Hello, world!")
 	scanner := bufio.NewScanner(os.Stdin)
+	if *op != "" {
+		main_algebra(scanner, *op, *otherPath, *bounds, *coalesce)
+		return
+	}
 	/*
 		Read the ranges
 		Consolidate ranges
-		Read the ingredients
-		Iterate ingredients and determine if in any ranges
+		Stream the ingredients and determine if in any ranges
 	*/
-	ranges, err := ReadRanges(scanner)
-	if err != nil {
-		log.Fatalf("Failure: %v", err)
+	var set *IntervalSet
+	var err error
+	if *indexPath != "" {
+		set, err = ReadIndex(*indexPath)
+		if err == nil && *coalesce {
+			set = set.Coalesced()
+		}
+	} else {
+		var ranges []Range
+		ranges, err = ReadRanges(scanner)
+		set = newIntervalSet(ranges, *coalesce)
 	}
-	ranges = ConsolidateRanges(ranges)
-	PrintRanges(ranges)
-	items, err := ReadItems(scanner)
 	if err != nil {
 		log.Fatalf("Failure: %v", err)
 	}
-	PrintItems(items)
-	itemLen := len(items)
-	rangeLen := len(ranges)
-	inRangeCount := 0
-	for i := 0; i < itemLen; i++ {
-		item := items[i]
-		for j := 0; j < rangeLen; j++ {
-			r := ranges[j]
-			if r.InRange(item) {
-				inRangeCount++
-				break
-			}
+	PrintRanges(set.Ranges())
+	if *writeIndexPath != "" {
+		err := WriteIndex(*writeIndexPath, set)
+		if err != nil {
+			log.Fatalf("Failure: %v", err)
 		}
 	}
-	fmt.Printf("In range count: %v", inRangeCount)
-
-	totalSpan := 0
-	for i := 0; i < rangeLen; i++ {
-		r := ranges[i]
-		totalSpan += r.RangeLen()
+	var out io.Writer
+	if *itemsOut == "-" {
+		out = os.Stdout
+	} else if *itemsOut != "" {
+		f, err := os.Create(*itemsOut)
+		if err != nil {
+			log.Fatalf("Failure: %v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	counts, err := StreamItems(scanner, set, out)
+	if err != nil {
+		log.Fatalf("Failure: %v", err)
 	}
+	fmt.Printf("In range count: %v\n", counts.Fresh)
+	fmt.Printf("Spoiled count: %v\n", counts.Spoiled)
+
+	totalSpan := set.Span()
 	fmt.Printf("Total range span: %v", totalSpan)
 }
 
-func ReadItems(scanner *bufio.Scanner) ([]int, error) {
-	items := make([]int, 0)
-	for scanner.Scan() {
-		line := scanner.Text()
-		item, err := strconv.Atoi(line)
+func main_serve(addr string, rangesPath string, coalesce bool, reloadInterval time.Duration) {
+	if rangesPath == "" {
+		log.Fatalf("Failure: -serve needs -ranges")
+	}
+	server, err := NewRangeServer(rangesPath, coalesce)
+	if err != nil {
+		log.Fatalf("Failure: %v", err)
+	}
+	if reloadInterval > 0 {
+		go server.Watch(reloadInterval, nil)
+	}
+	log.Printf("Serving %v on %v", rangesPath, addr)
+	err = http.ListenAndServe(addr, server.Handler())
+	if err != nil {
+		log.Fatalf("Failure: %v", err)
+	}
+}
+
+// main_algebra prints the result of a set operation between the ranges on
+// stdin and the ones in otherPath, or the gaps in the ranges on stdin.
+func main_algebra(scanner *bufio.Scanner, op string, otherPath string, bounds string, coalesce bool) {
+	ranges, err := ReadRanges(scanner)
+	if err != nil {
+		log.Fatalf("Failure: %v", err)
+	}
+	set := newIntervalSet(ranges, coalesce)
+	var other *IntervalSet
+	if op != "complement" {
+		if otherPath == "" {
+			log.Fatalf("Failure: -op %v needs -other", op)
+		}
+		other, err = readRangeFile(otherPath, coalesce)
 		if err != nil {
-			return nil, err
+			log.Fatalf("Failure: %v", err)
 		}
-		items = append(items, item)
 	}
-	return items, nil
+	var result *IntervalSet
+	switch op {
+	case "union":
+		result = set.Union(other)
+	case "intersect":
+		result = set.Intersect(other)
+	case "difference":
+		result = set.Difference(other)
+	case "symdiff":
+		result = set.SymmetricDifference(other)
+	case "complement":
+		all := set.Ranges()
+		if len(all) == 0 && bounds == "" {
+			log.Fatalf("Failure: -op complement of no ranges needs -bounds")
+		}
+		var b Range
+		if bounds == "" {
+			b = NewRange(all[0].lower, all[len(all)-1].upper)
+		} else {
+			b, err = ParseRange(bounds)
+			if err != nil {
+				log.Fatalf("Failure: %v", err)
+			}
+		}
+		result = set.Complement(b)
+	default:
+		log.Fatalf("Failure: unknown operation %q", op)
+	}
+	PrintRanges(result.Ranges())
+	fmt.Printf("Total range span: %v", result.Span())
+}
+
+func readRangeFile(path string, coalesce bool) (*IntervalSet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ranges, err := ReadRanges(bufio.NewScanner(f))
+	if err != nil {
+		return nil, err
+	}
+	return newIntervalSet(ranges, coalesce), nil
 }
 
 func ReadRanges(scanner *bufio.Scanner) ([]Range, error) {
@@ -90,20 +196,66 @@ func ReadRanges(scanner *bufio.Scanner) ([]Range, error) {
 		if line == "" {
 			break
 		}
-		rangeParts := strings.Split(line, "-")
-		lower, err := strconv.Atoi(rangeParts[0])
-		if err != nil {
-			return nil, err
-		}
-		upper, err := strconv.Atoi(rangeParts[1])
+		r, err := ParseRange(line)
 		if err != nil {
 			return nil, err
 		}
-		ranges = append(ranges, NewRange(lower, upper))
+		ranges = append(ranges, r)
 	}
 	return ranges, nil
 }
 
+/*
+ParseRange parses a range of integers, which may be negative. A range is either inclusive, written "lower-upper" as
+in the puzzle, or in interval notation with a bracket for an inclusive bound and a parenthesis for an exclusive one,
+like "[-3,5)". Exclusive bounds are moved inwards, so the range is always stored inclusive and RangeLen stays a plain
+count. Empty ranges are rejected, including an exclusive bound at the edge of int, which has nowhere to move.
+*/
+func ParseRange(line string) (Range, error) {
+	line = strings.TrimSpace(line)
+	var lowerStr, upperStr string
+	lowerOpen, upperOpen := false, false
+	if strings.HasPrefix(line, "[") || strings.HasPrefix(line, "(") {
+		if !strings.HasSuffix(line, "]") && !strings.HasSuffix(line, ")") {
+			return Range{}, fmt.Errorf("Range %q is missing its closing bracket", line)
+		}
+		rangeParts := strings.Split(line[1:len(line)-1], ",")
+		if len(rangeParts) != 2 {
+			return Range{}, fmt.Errorf("Range must look like [lower,upper]. Got %q", line)
+		}
+		lowerStr, upperStr = rangeParts[0], rangeParts[1]
+		lowerOpen, upperOpen = line[0] == '(', line[len(line)-1] == ')'
+	} else {
+		// the separator is the first '-' that is not the sign of lower
+		sep := strings.Index(line[min(1, len(line)):], "-") + 1
+		if sep < 1 {
+			return Range{}, fmt.Errorf("Range must look like lower-upper. Got %q", line)
+		}
+		lowerStr, upperStr = line[:sep], line[sep+1:]
+	}
+	lower, err := strconv.Atoi(strings.TrimSpace(lowerStr))
+	if err != nil {
+		return Range{}, err
+	}
+	upper, err := strconv.Atoi(strings.TrimSpace(upperStr))
+	if err != nil {
+		return Range{}, err
+	}
+	if (lowerOpen && lower == math.MaxInt) || (upperOpen && upper == math.MinInt) {
+		return Range{}, fmt.Errorf("Range %q is empty", line)
+	}
+	if lowerOpen {
+		lower++
+	}
+	if upperOpen {
+		upper--
+	}
+	if lower > upper {
+		return Range{}, fmt.Errorf("Range %q is empty", line)
+	}
+	return NewRange(lower, upper), nil
+}
+
 func PrintRanges(ranges []Range) {
 	rangeLen := len(ranges)
 	for i := 0; i < rangeLen; i++ {
@@ -112,15 +264,17 @@ func PrintRanges(ranges []Range) {
 	}
 }
 
-func PrintItems(items []int) {
-	itemLen := len(items)
-	for i := 0; i < itemLen; i++ {
-		item := items[i]
-		fmt.Printf("Ingredient %5v: %15v\n", i, item)
-	}
+func ConsolidateRanges(ranges []Range) []Range {
+	return consolidateRanges(ranges, false)
 }
 
-func ConsolidateRanges(ranges []Range) []Range {
+// CoalesceRanges is ConsolidateRanges, but also merges ranges that touch
+// without overlapping, like 3-5 and 6-8.
+func CoalesceRanges(ranges []Range) []Range {
+	return consolidateRanges(ranges, true)
+}
+
+func consolidateRanges(ranges []Range, adjacent bool) []Range {
 	lenRanges := len(ranges)
 	SortRanges(ranges)
 	consolidatedRanges := make([]Range, 0)
@@ -133,7 +287,7 @@ func ConsolidateRanges(ranges []Range) []Range {
 			upperR := ranges[j]
 			upperLower := upperR.lower
 			upperUpper := upperR.upper
-			if upperLower <= upper {
+			if upperLower <= upper || (adjacent && upperLower-1 == upper) {
 				jump++
 				if upperUpper > upper {
 					upper = upperUpper
@@ -150,6 +304,6 @@ func ConsolidateRanges(ranges []Range) []Range {
 
 func SortRanges(ranges []Range) {
 	slices.SortFunc(ranges, func(a, b Range) int {
-		return a.lower - b.lower
+		return cmp.Compare(a.lower, b.lower)
 	})
 }
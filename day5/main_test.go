@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"math"
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func Test_ParseRange(t *testing.T) {
+	data := []struct {
+		name     string
+		line     string
+		expected Range
+		valid    bool
+	}{
+		{"puzzle", "3-5", NewRange(3, 5), true},
+		{"single", "7-7", NewRange(7, 7), true},
+		{"negative lower", "-5-3", NewRange(-5, 3), true},
+		{"both negative", "-5--3", NewRange(-5, -3), true},
+		{"closed", "[-2,4]", NewRange(-2, 4), true},
+		{"half open", "[3,6)", NewRange(3, 5), true},
+		{"open lower", "(3,6]", NewRange(4, 6), true},
+		{"open", "( -3 , 0 )", NewRange(-2, -1), true},
+		{"empty", "[3,3)", Range{}, false},
+		{"reversed", "5-3", Range{}, false},
+		{"missing separator", "35", Range{}, false},
+		{"missing bracket", "[3,5", Range{}, false},
+		{"too many bounds", "[1,2,3]", Range{}, false},
+		{"not a number", "3-x", Range{}, false},
+		{"int extremes", "-9223372036854775808-9223372036854775807", NewRange(math.MinInt, math.MaxInt), true},
+		{"open at max", "(9223372036854775807,9223372036854775807]", Range{}, false},
+		{"open at min", "[-9223372036854775808,-9223372036854775808)", Range{}, false},
+		{"open below max", "(9223372036854775806,9223372036854775807]", NewRange(math.MaxInt, math.MaxInt), true},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			r, err := ParseRange(d.line)
+			if (err == nil) != d.valid {
+				t.Fatalf("Expected valid %v, got error %v", d.valid, err)
+			}
+			if r != d.expected {
+				t.Errorf("Expected %v, got %v", d.expected, r)
+			}
+		})
+	}
+}
+
+func Test_RangeLen_extremes(t *testing.T) {
+	full := new(big.Int).Lsh(big.NewInt(1), 64)
+	data := []struct {
+		name     string
+		r        Range
+		expected *big.Int
+	}{
+		{"min", NewRange(math.MinInt, math.MinInt), big.NewInt(1)},
+		{"max", NewRange(math.MaxInt, math.MaxInt), big.NewInt(1)},
+		{"negative half", NewRange(math.MinInt, -1), new(big.Int).Lsh(big.NewInt(1), 63)},
+		{"all of int", NewRange(math.MinInt, math.MaxInt), full},
+	}
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			if got := d.r.RangeLen(); got.Cmp(d.expected) != 0 {
+				t.Errorf("Expected %v, got %v", d.expected, got)
+			}
+		})
+	}
+	halves := []Range{NewRange(0, math.MaxInt), NewRange(math.MinInt, -1)}
+	for _, set := range []*IntervalSet{NewIntervalSet(halves), NewCoalescedIntervalSet(halves)} {
+		if set.Span().Cmp(full) != 0 {
+			t.Errorf("Expected %v, got %v", full, set.Span())
+		}
+	}
+	loaded, err := DecodeIndex(EncodeIndex(NewCoalescedIntervalSet(halves)))
+	if err != nil || loaded.Span().Cmp(full) != 0 {
+		t.Errorf("Expected %v, got %v (%v)", full, loaded, err)
+	}
+}
+
+func Test_CoalesceRanges(t *testing.T) {
+	ranges := []Range{NewRange(6, 8), NewRange(-3, -1), NewRange(3, 5), NewRange(0, 1), NewRange(10, 12)}
+	consolidated := ConsolidateRanges(slices.Clone(ranges))
+	if len(consolidated) != 5 {
+		t.Errorf("Expected 5 ranges, got %v", consolidated)
+	}
+	expected := []Range{NewRange(-3, 1), NewRange(3, 8), NewRange(10, 12)}
+	coalesced := CoalesceRanges(slices.Clone(ranges))
+	if !slices.Equal(coalesced, expected) {
+		t.Errorf("Expected %v, got %v", expected, coalesced)
+	}
+	for _, set := range []*IntervalSet{NewIntervalSet(ranges), NewCoalescedIntervalSet(ranges)} {
+		if set.Span().Int64() != 14 {
+			t.Errorf("Expected 14, got %v", set.Span())
+		}
+	}
+	gaps := NewCoalescedIntervalSet(ranges).Complement(NewRange(-5, 15))
+	expectedGaps := []Range{NewRange(-5, -4), NewRange(2, 2), NewRange(9, 9), NewRange(13, 15)}
+	if !slices.Equal(gaps.Ranges(), expectedGaps) {
+		t.Errorf("Expected %v, got %v", expectedGaps, gaps.Ranges())
+	}
+}
+
+func Test_StreamItems(t *testing.T) {
+	set := NewIntervalSet([]Range{NewRange(3, 5), NewRange(10, 14), NewRange(16, 20), NewRange(12, 18)})
+	var out strings.Builder
+	counts, err := StreamItems(bufio.NewScanner(strings.NewReader("1\n5\n8\n\n11\n17\n32\n")), set, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts != (ItemCounts{Fresh: 3, Spoiled: 3}) {
+		t.Errorf("Expected 3 and 3, got %v", counts)
+	}
+	expected := "1 spoiled\n5 fresh\n8 spoiled\n11 fresh\n17 fresh\n32 spoiled\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+	_, err = StreamItems(bufio.NewScanner(strings.NewReader("1\nx\n")), set, nil)
+	if err == nil {
+		t.Errorf("Expected an error for a bad ingredient")
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxBatchBytes caps the size of a batched lookup request body.
+const maxBatchBytes = 16 << 20
+
+// LoadRangeFile loads a set from either an index file or a text file of
+// ranges, one per line, as in the range section of the puzzle input.
+func LoadRangeFile(path string, coalesce bool) (*IntervalSet, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(buf, []byte(indexMagic)) {
+		set, err := DecodeIndex(buf)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", path, err)
+		}
+		if coalesce {
+			set = set.Coalesced()
+		}
+		return set, nil
+	}
+	ranges, err := ReadRanges(bufio.NewScanner(bytes.NewReader(buf)))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return newIntervalSet(ranges, coalesce), nil
+}
+
+/*
+RangeServer answers freshness lookups over HTTP:
+
+	GET  /lookup?id=5&id=17  looks up one or more IDs
+	POST /lookup             looks up {"ids": [5, 17]}
+	POST /reload             reloads the range file
+	GET  /stats              returns the number of ranges and their span
+
+Lookups read the current set under a read lock, and a reload builds the new set before swapping it in, so lookups
+are never blocked by parsing and never see a half loaded set. Reloads, from POST /reload or Watch, are serialized
+from the stat to the swap, so an older read of the file can never replace a newer one. A failed reload keeps the
+old set.
+*/
+type RangeServer struct {
+	path     string
+	coalesce bool
+
+	// reloadMu is held for a whole reload, mu only for the swap
+	reloadMu sync.Mutex
+	mu       sync.RWMutex
+	set      *IntervalSet
+	modTime  time.Time
+	loadedAt time.Time
+}
+
+func NewRangeServer(path string, coalesce bool) (*RangeServer, error) {
+	s := &RangeServer{path: path, coalesce: coalesce}
+	err := s.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload loads the range file again and swaps it in.
+func (s *RangeServer) Reload() error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	set, err := LoadRangeFile(s.path, s.coalesce)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set, s.modTime, s.loadedAt = set, info.ModTime(), time.Now()
+	return nil
+}
+
+// Watch reloads the range file every interval if it changed since the last
+// load, until stop is closed. Failed reloads are logged.
+func (s *RangeServer) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(s.path)
+		if err != nil {
+			log.Printf("Reload failed: %v", err)
+			continue
+		}
+		s.mu.RLock()
+		changed := !info.ModTime().Equal(s.modTime)
+		s.mu.RUnlock()
+		if !changed {
+			continue
+		}
+		if err := s.Reload(); err != nil {
+			log.Printf("Reload failed: %v", err)
+		} else {
+			log.Printf("Reloaded %v", s.path)
+		}
+	}
+}
+
+func (s *RangeServer) current() *IntervalSet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.set
+}
+
+type rangeJSON struct {
+	Lower int `json:"lower"`
+	Upper int `json:"upper"`
+}
+
+// LookupResult is the answer for one ID. Range is the range covering it,
+// and is left out for spoiled IDs.
+type LookupResult struct {
+	ID    int        `json:"id"`
+	Fresh bool       `json:"fresh"`
+	Range *rangeJSON `json:"range,omitempty"`
+}
+
+type lookupRequest struct {
+	IDs []int `json:"ids"`
+}
+
+type lookupResponse struct {
+	Results []LookupResult `json:"results"`
+}
+
+type statsResponse struct {
+	Ranges   int       `json:"ranges"`
+	Span     *big.Int  `json:"span"`
+	LoadedAt time.Time `json:"loaded_at"`
+}
+
+func lookup(set *IntervalSet, ids []int) []LookupResult {
+	results := make([]LookupResult, len(ids))
+	for k, id := range ids {
+		results[k] = LookupResult{ID: id}
+		if r, _, ok := set.Find(id); ok {
+			results[k].Fresh = true
+			results[k].Range = &rangeJSON{Lower: r.lower, Upper: r.upper}
+		}
+	}
+	return results
+}
+
+func (s *RangeServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /lookup", s.handleLookupQuery)
+	mux.HandleFunc("POST /lookup", s.handleLookupBatch)
+	mux.HandleFunc("POST /reload", s.handleReload)
+	mux.HandleFunc("GET /stats", s.handleStats)
+	return mux
+}
+
+func (s *RangeServer) handleLookupQuery(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()["id"]
+	if len(values) == 0 {
+		http.Error(w, "Missing id", http.StatusBadRequest)
+		return
+	}
+	ids := make([]int, len(values))
+	for k, v := range values {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid id %q", v), http.StatusBadRequest)
+			return
+		}
+		ids[k] = id
+	}
+	writeJSON(w, lookupResponse{Results: lookup(s.current(), ids)})
+}
+
+func (s *RangeServer) handleLookupBatch(w http.ResponseWriter, r *http.Request) {
+	var req lookupRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBytes)).Decode(&req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, lookupResponse{Results: lookup(s.current(), req.IDs)})
+}
+
+func (s *RangeServer) handleReload(w http.ResponseWriter, r *http.Request) {
+	err := s.Reload()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Reload failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+	s.handleStats(w, r)
+}
+
+func (s *RangeServer) handleStats(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	stats := statsResponse{Ranges: s.set.Len(), Span: s.set.Span(), LoadedAt: s.loadedAt}
+	s.mu.RUnlock()
+	writeJSON(w, stats)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
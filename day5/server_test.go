@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func getLookup(t *testing.T, resp *http.Response) []LookupResult {
+	t.Helper()
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+	var body lookupResponse
+	err := json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body.Results
+}
+
+func Test_RangeServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ranges.txt")
+	err := os.WriteFile(path, []byte("3-5\n10-14\n16-20\n12-18\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := NewRangeServer(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/lookup?id=17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := getLookup(t, resp)
+	if len(results) != 1 || !results[0].Fresh || *results[0].Range != (rangeJSON{Lower: 10, Upper: 20}) {
+		t.Errorf("Unexpected results %+v", results)
+	}
+
+	resp, err = http.Post(ts.URL+"/lookup", "application/json", strings.NewReader(`{"ids": [1, 5, 8, 11, 17, 32]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results = getLookup(t, resp)
+	fresh := make([]bool, len(results))
+	for k, r := range results {
+		fresh[k] = r.Fresh
+		if r.Fresh != (r.Range != nil) {
+			t.Errorf("ID %v: range %v does not match fresh %v", r.ID, r.Range, r.Fresh)
+		}
+	}
+	if expected := []bool{false, true, false, true, true, false}; !slices.Equal(fresh, expected) {
+		t.Errorf("Expected %v, got %v", expected, fresh)
+	}
+
+	for _, bad := range []string{"/lookup", "/lookup?id=x"} {
+		resp, err = http.Get(ts.URL + bad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%v: expected %v, got %v", bad, http.StatusBadRequest, resp.StatusCode)
+		}
+	}
+
+	// a broken file is refused and the old ranges stay in place
+	err = os.WriteFile(path, []byte("3-x\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = http.Post(ts.URL+"/reload", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected %v, got %v", http.StatusInternalServerError, resp.StatusCode)
+	}
+
+	// reloading an index file picks up the new ranges
+	err = WriteIndex(path, NewIntervalSet([]Range{NewRange(30, 40)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = http.Post(ts.URL+"/reload", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats statsResponse
+	err = json.NewDecoder(resp.Body).Decode(&stats)
+	resp.Body.Close()
+	if err != nil || stats.Ranges != 1 || stats.Span.Int64() != 11 {
+		t.Errorf("Unexpected stats %+v, %v", stats, err)
+	}
+	resp, err = http.Get(ts.URL + "/lookup?id=17&id=32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results = getLookup(t, resp)
+	if results[0].Fresh || !results[1].Fresh {
+		t.Errorf("Unexpected results %+v", results)
+	}
+}
+
+func Test_RangeServer_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ranges.txt")
+	err := os.WriteFile(path, []byte("3-5\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := NewRangeServer(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+	stop := make(chan struct{})
+	defer close(stop)
+	go server.Watch(5*time.Millisecond, stop)
+
+	err = os.WriteFile(path, []byte("3-5\n7-9\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// make sure the change is visible even on coarse file system clocks
+	later := time.Now().Add(time.Hour)
+	err = os.Chtimes(path, later, later)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		resp, err := http.Get(ts.URL + "/lookup?id=8")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if getLookup(t, resp)[0].Fresh {
+			return
+		}
+	}
+	t.Errorf("Expected the range file to be reloaded")
+}
+
+// Test_RangeServer_concurrentReload runs explicit reloads alongside Watch
+// while the file changes, and checks that the last version wins.
+func Test_RangeServer_concurrentReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ranges.txt")
+	err := os.WriteFile(path, []byte("0-0\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := NewRangeServer(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	go server.Watch(time.Millisecond, stop)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := 0; k < 50; k++ {
+				server.Reload()
+			}
+		}()
+	}
+	for version := 1; version <= 50; version++ {
+		err := os.WriteFile(path, []byte(fmt.Sprintf("%v-%v\n", version, version)), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	close(stop)
+	err = server.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !server.current().Contains(50) || server.current().Len() != 1 {
+		t.Errorf("Expected only 50-50, got %v", server.current().Ranges())
+	}
+}
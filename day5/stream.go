@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ItemCounts is how many ingredients were fresh, i.e. in a range, and how
+// many were spoiled.
+type ItemCounts struct {
+	Fresh   int
+	Spoiled int
+}
+
+/*
+StreamItems checks the ingredients one line at a time against the set, so memory does not grow with the number of
+ingredients. When out is not nil, every ingredient is written to it as "id fresh" or "id spoiled", in input order.
+Blank lines are skipped.
+*/
+func StreamItems(scanner *bufio.Scanner, set *IntervalSet, out io.Writer) (ItemCounts, error) {
+	var counts ItemCounts
+	var w *bufio.Writer
+	if out != nil {
+		w = bufio.NewWriter(out)
+	}
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		item, err := strconv.Atoi(line)
+		if err != nil {
+			return counts, fmt.Errorf("Ingredient line %v: %v", lineNum, err)
+		}
+		fresh := set.Contains(item)
+		if fresh {
+			counts.Fresh++
+		} else {
+			counts.Spoiled++
+		}
+		if w == nil {
+			continue
+		}
+		status := "spoiled"
+		if fresh {
+			status = "fresh"
+		}
+		_, err = fmt.Fprintf(w, "%v %v\n", item, status)
+		if err != nil {
+			return counts, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return counts, err
+	}
+	if w != nil {
+		return counts, w.Flush()
+	}
+	return counts, nil
+}